@@ -0,0 +1,123 @@
+package libjson
+
+import (
+	"unsafe"
+)
+
+// amount of elements of a single slab block
+const slabSize = 4096
+
+// slab hands out sub slices of large blocks, the blocks are kept and reused
+// after a reset
+type slab[T any] struct {
+	blocks [][]T
+	// index of the current block
+	cur int
+	// offset into the current block
+	off int
+}
+
+func (s *slab[T]) alloc(n int) []T {
+	for s.cur < len(s.blocks) {
+		b := s.blocks[s.cur]
+		if s.off+n <= len(b) {
+			r := b[s.off : s.off+n : s.off+n]
+			s.off += n
+			return r
+		}
+		s.cur++
+		s.off = 0
+	}
+	s.blocks = append(s.blocks, make([]T, max(slabSize, n)))
+	s.off = n
+	return s.blocks[s.cur][:n:n]
+}
+
+func (s *slab[T]) reset() {
+	for i := 0; i < len(s.blocks) && i <= s.cur; i++ {
+		// drop references into the previous trees for the gc
+		clear(s.blocks[i])
+	}
+	s.cur = 0
+	s.off = 0
+}
+
+// eface is the runtime representation of an any
+type eface struct {
+	typ  unsafe.Pointer
+	data unsafe.Pointer
+}
+
+var (
+	floatType  = typeOf(float64(0))
+	stringType = typeOf("")
+	sliceType  = typeOf([]any(nil))
+)
+
+func typeOf(v any) unsafe.Pointer {
+	return (*eface)(unsafe.Pointer(&v)).typ
+}
+
+// Arena carves arrays, numbers and strings of parsed trees out of large
+// slabs, instead of allocating each of them on its own, see UseArena. Objects
+// are still allocated as maps. An Arena is not safe for concurrent use.
+type Arena struct {
+	anys    slab[any]
+	floats  slab[float64]
+	strings slab[string]
+	slices  slab[[]any]
+}
+
+func NewArena() *Arena {
+	return &Arena{}
+}
+
+// Reset makes the memory of all previously parsed trees available for reuse,
+// thus these trees must no longer be used
+func (a *Arena) Reset() {
+	a.anys.reset()
+	a.floats.reset()
+	a.strings.reset()
+	a.slices.reset()
+}
+
+// array returns a slice holding a copy of elems
+func (a *Arena) array(elems []any) []any {
+	r := a.anys.alloc(len(elems))
+	copy(r, elems)
+	return r
+}
+
+// float boxes f into an any pointing into the slab, instead of letting the
+// runtime allocate 8 bytes for it
+func (a *Arena) float(f float64) any {
+	s := a.floats.alloc(1)
+	s[0] = f
+	var r any
+	e := (*eface)(unsafe.Pointer(&r))
+	e.typ = floatType
+	e.data = unsafe.Pointer(&s[0])
+	return r
+}
+
+// string boxes str, see Arena.float
+func (a *Arena) string(str string) any {
+	s := a.strings.alloc(1)
+	s[0] = str
+	var r any
+	e := (*eface)(unsafe.Pointer(&r))
+	e.typ = stringType
+	e.data = unsafe.Pointer(&s[0])
+	return r
+}
+
+// slice boxes the header of arr, see Arena.float
+func (a *Arena) slice(arr []any) any {
+	s := a.slices.alloc(1)
+	s[0] = arr
+	var r any
+	e := (*eface)(unsafe.Pointer(&r))
+	e.typ = sliceType
+	e.data = unsafe.Pointer(&s[0])
+	return r
+}
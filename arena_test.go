@@ -0,0 +1,55 @@
+package libjson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArena(t *testing.T) {
+	input := []string{
+		`[1, "two", true, null, [], {}, [3, [4.5, "six"]]]`,
+		`{"key": [1, 2, 3], "obj": {"str": "value", "num": -12e3}}`,
+		`"str"`,
+		"12",
+		string(genData(1)),
+	}
+	a := NewArena()
+	for _, in := range input {
+		want, err := New([]byte(in))
+		assert.NoError(t, err)
+		got, err := New([]byte(in), UseArena(a))
+		assert.NoError(t, err)
+		assert.EqualValues(t, want, got)
+	}
+
+	// trees stay valid until the arena is reset
+	first, err := New([]byte(`[1, "a", [2]]`), UseArena(a))
+	assert.NoError(t, err)
+	for range 3 {
+		_, err := New(genData(1), UseArena(a))
+		assert.NoError(t, err)
+	}
+	assert.EqualValues(t, []any{1.0, "a", []any{2.0}}, first.obj)
+
+	a.Reset()
+	got, err := New([]byte(`[3, "b", [4]]`), UseArena(a))
+	assert.NoError(t, err)
+	assert.EqualValues(t, []any{3.0, "b", []any{4.0}}, got.obj)
+
+	_, err = New([]byte(`[1, 2,]`), UseArena(a))
+	assert.Error(t, err)
+}
+
+func TestArenaAllocs(t *testing.T) {
+	d := genData(1)
+	a := NewArena()
+	without := testing.AllocsPerRun(5, func() {
+		_, _ = New(d)
+	})
+	with := testing.AllocsPerRun(5, func() {
+		a.Reset()
+		_, _ = New(d, UseArena(a))
+	})
+	assert.Less(t, with, without*0.6)
+}
@@ -0,0 +1,74 @@
+package libjson
+
+import (
+	"cmp"
+	"fmt"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Canonical makes (*JSON).Append, LinesWriter and SeqWriter emit the JSON
+// Canonicalization Scheme of rfc8785, see Canonicalize. Overrides
+// EncodeNonFinite.
+func Canonical() Option {
+	return func(c *config) {
+		c.canonical = true
+	}
+}
+
+// Canonicalize returns the canonical representation of j as specified by the
+// JSON Canonicalization Scheme (rfc8785), suitable for hashing and signing:
+// no whitespace, object members sorted by the UTF-16 code units of their
+// keys, numbers serialized as by ECMAScript and strings escaping only quotes,
+// backslashes and control characters. Non-finite numbers and strings
+// containing invalid utf8 are rejected.
+func Canonicalize(j *JSON) ([]byte, error) {
+	return j.Append(nil, Canonical())
+}
+
+// appendCanonicalString appends s as AppendString does, rejecting invalid
+// utf8 instead of replacing it
+func appendCanonicalString(buf []byte, s string) ([]byte, error) {
+	if !utf8.ValidString(s) {
+		return buf, fmt.Errorf("Unsupported string %q, invalid utf8 can not be canonicalized", s)
+	}
+	return AppendString(buf, s), nil
+}
+
+// appendCanonicalFloat appends f as specified by ECMAScript's
+// Number.prototype.toString, which AppendFloat matches except for -0
+func appendCanonicalFloat(buf []byte, f float64) ([]byte, error) {
+	if f == 0 {
+		return append(buf, '0'), nil
+	}
+	return NonFiniteError.AppendFloat(buf, f, 64)
+}
+
+// compareUTF16 compares a and b by their UTF-16 code units, differing from
+// the byte order of utf8 for characters beyond U+FFFF, whose surrogates sort
+// before U+E000 to U+FFFF
+func compareUTF16(a, b string) int {
+	for a != "" && b != "" {
+		ra, na := utf8.DecodeRuneInString(a)
+		rb, nb := utf8.DecodeRuneInString(b)
+		if ra != rb {
+			ua, la := utf16Units(ra)
+			ub, lb := utf16Units(rb)
+			if ua != ub {
+				return cmp.Compare(ua, ub)
+			}
+			// same high surrogate
+			return cmp.Compare(la, lb)
+		}
+		a, b = a[na:], b[nb:]
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+// utf16Units returns the code unit of r, or its surrogate pair
+func utf16Units(r rune) (rune, rune) {
+	if r < 0x10000 {
+		return r, 0
+	}
+	return utf16.EncodeRune(r)
+}
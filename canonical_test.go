@@ -0,0 +1,86 @@
+package libjson
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonicalize(t *testing.T) {
+	input := map[string]string{
+		// rfc8785 section 3.2.2
+		`{
+			"numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+			"string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+			"literals": [null, true, false]
+		}`: `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`,
+		// rfc8785 section 3.2.3
+		`{
+			"\u20ac": "Euro Sign",
+			"\r": "Carriage Return",
+			"\ufb33": "Hebrew Letter Dalet With Dagesh",
+			"1": "One",
+			"\ud83d\ude00": "Emoji: Grinning Face",
+			"\u0080": "Control",
+			"\u00f6": "Latin Small Letter O With Diaeresis"
+		}`: "{\"\\r\":\"Carriage Return\",\"1\":\"One\",\"\u0080\":\"Control\",\"ö\":\"Latin Small Letter O With Diaeresis\",\"€\":\"Euro Sign\",\"😀\":\"Emoji: Grinning Face\",\"\ufb33\":\"Hebrew Letter Dalet With Dagesh\"}",
+		// rfc8785 appendix B
+		`[0, -0, 5e-324, -5e-324, 1.7976931348623157e308, 9007199254740992, 295147905179352830000, 1e21, 1e-7, 0.000001, 333333333.3333332]`: `[0,0,5e-324,-5e-324,1.7976931348623157e+308,9007199254740992,295147905179352830000,1e+21,1e-7,0.000001,333333333.3333332]`,
+		`"\u2028\u0000\u001f\u007f<>&"`:      "\"\u2028\\u0000\\u001f\u007f<>&\"",
+		`{"b": {"d": [], "c": {}}, "a": ""}`: `{"a":"","b":{"c":{},"d":[]}}`,
+	}
+	for in, want := range input {
+		t.Run(want, func(t *testing.T) {
+			j, err := New([]byte(in))
+			assert.NoError(t, err)
+			out, err := Canonicalize(&j)
+			assert.NoError(t, err)
+			assert.Equal(t, want, string(out))
+		})
+	}
+}
+
+func TestCanonicalizeFail(t *testing.T) {
+	input := []any{
+		math.NaN(),
+		[]any{math.Inf(1)},
+		"\xff",
+		map[string]any{"\xff": 1.0},
+	}
+	for _, i := range input {
+		_, err := Canonicalize(&JSON{obj: i})
+		assert.Error(t, err)
+		// the policy for non-finite numbers does not apply
+		_, err = (&JSON{obj: i}).Append(nil, EncodeNonFinite(NonFiniteNull), Canonical())
+		assert.Error(t, err)
+	}
+}
+
+func TestCompareUTF16(t *testing.T) {
+	input := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"a", "ab", -1},
+		{"b", "ab", 1},
+		{"\uffff", "😀", 1},
+		{"\ue000", "😀", 1},
+		{"\ud7ff", "😀", -1},
+		{"😀", "😁", -1},
+		{"😀", "😀", 0},
+	}
+	for _, i := range input {
+		assert.Equal(t, i.want, compareUTF16(i.a, i.b), "%q <=> %q", i.a, i.b)
+	}
+}
+
+func TestCanonicalWriter(t *testing.T) {
+	b := bytes.Buffer{}
+	w := NewLinesWriter(&b, Canonical())
+	assert.NoError(t, w.Write(&JSON{obj: map[string]any{"😀": -0.0, "\uffff": 1e21}}))
+	assert.Error(t, w.Write(&JSON{obj: math.Inf(-1)}))
+	assert.Equal(t, "{\"😀\":0,\"\uffff\":1e+21}\n", b.String())
+}
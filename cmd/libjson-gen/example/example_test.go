@@ -0,0 +1,140 @@
+package example
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xnacly/libjson"
+)
+
+func person() Person {
+	nick := "jd"
+	pnick := &nick
+	rank := int64(-3)
+	return Person{
+		Base:     Base{ID: 1 << 60, Created: "2024-01-01"},
+		Name:     "John Doe",
+		Age:      42,
+		Score:    1e-7,
+		Ratio:    0.1,
+		Active:   true,
+		Role:     "admin",
+		Address:  Address{Street: "Main", Zip: 12345},
+		Previous: &Address{Street: "Old"},
+		Tags:     []string{"a", "b"},
+		Friends:  []*Person{{Name: "Jane"}, nil},
+		Labels:   map[string]string{"b": "2", "a": "1"},
+		Roles:    map[Role][]Address{"x": {{Street: "X"}}, "y": nil},
+		Nested:   [][]int8{{-128, 127}, nil, {}},
+		Nick:     &pnick,
+		Rank:     &rank,
+	}
+}
+
+func TestMarshalMatchesEncodingJson(t *testing.T) {
+	for _, p := range []Person{person(), {}, {Name: "\"quoted\"\n\t\\ \x01 \u00e4", Tags: []string{}}} {
+		got, err := p.MarshalLibJSON()
+		assert.NoError(t, err)
+		want, err := json.Marshal(&p)
+		assert.NoError(t, err)
+		assert.Equal(t, string(want), string(got))
+	}
+}
+
+func TestRoundtrip(t *testing.T) {
+	escaped := Person{
+		Name:   "a\"b\nc \"quoted\"\n\t\\ \x01 \u00e4 \U0001f600",
+		Labels: map[string]string{"\"key\"\n": "\\"},
+	}
+	for _, p := range []Person{person(), escaped} {
+		data, err := p.MarshalLibJSON()
+		assert.NoError(t, err)
+
+		var got Person
+		assert.NoError(t, got.UnmarshalLibJSON(data))
+		assert.Equal(t, p, got)
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	input := `{
+		"id": "7",
+		"name": "Jane",
+		"unknown": {"skipped": [1, {"a": null}]},
+		"age": null,
+		"previous": null,
+		"tags": ["x"],
+		"address": {"street": "Main", "zip": 1},
+		"labels": {"k": "v"},
+		"nick": "j",
+		"rank": "12"
+	}`
+	p := Person{Age: 3, Previous: &Address{}}
+	assert.NoError(t, p.UnmarshalLibJSON([]byte(input)))
+	assert.EqualValues(t, 7, p.ID)
+	assert.Equal(t, "Jane", p.Name)
+	assert.Equal(t, 3, p.Age)
+	assert.Nil(t, p.Previous)
+	assert.Equal(t, []string{"x"}, p.Tags)
+	assert.Equal(t, Address{Street: "Main", Zip: 1}, p.Address)
+	assert.Equal(t, map[string]string{"k": "v"}, p.Labels)
+	assert.Equal(t, "j", **p.Nick)
+	assert.EqualValues(t, 12, *p.Rank)
+
+	var e Person
+	assert.NoError(t, e.UnmarshalLibJSON([]byte(`null`)))
+	assert.Equal(t, Person{}, e)
+}
+
+func TestUnmarshalFail(t *testing.T) {
+	input := []string{
+		``,
+		`[]`,
+		`{"name": 1}`,
+		`{"age": 1.5}`,
+		`{"address": {"zip": 65536}}`,
+		`{"id": 7}`,
+		`{"active": "yes"}`,
+		`{"rank": 12}`,
+		`{"tags": ["a",]}`,
+		`{"name": "a",}`,
+		`{"name": "a"} {}`,
+		`{"name" "a"}`,
+		`{"tags": {}}`,
+	}
+	for _, i := range input {
+		t.Run(i, func(t *testing.T) {
+			var p Person
+			assert.Error(t, p.UnmarshalLibJSON([]byte(i)))
+		})
+	}
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	p := person()
+	data, _ := p.MarshalLibJSON()
+	b.Run("libjson-gen", func(b *testing.B) {
+		for range b.N {
+			var p Person
+			if err := p.UnmarshalLibJSON(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("libjson.New", func(b *testing.B) {
+		for range b.N {
+			if _, err := libjson.New(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("encoding/json", func(b *testing.B) {
+		for range b.N {
+			var p Person
+			if err := json.Unmarshal(data, &p); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
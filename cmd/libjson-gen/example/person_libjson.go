@@ -0,0 +1,672 @@
+// Code generated by libjson-gen; DO NOT EDIT.
+
+package example
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/xnacly/libjson"
+)
+
+// DecodeLibJSON decodes the next value of d into v, null leaves v unchanged
+func (v *Person) DecodeLibJSON(d *libjson.Decoder) error {
+	if null, err := d.Null(); err != nil || null {
+		return err
+	}
+	if _, err := d.Expect(libjson.TokenObjectStart); err != nil {
+		return err
+	}
+	for d.More() {
+		key, err := d.ReadKeyBytes()
+		if err != nil {
+			return err
+		}
+		switch string(key) {
+		case "id":
+			if null, err := d.Null(); err != nil {
+				return err
+			} else if !null {
+				s, err := d.ReadString()
+				if err != nil {
+					return err
+				}
+				x, err := strconv.ParseInt(s, 10, 64)
+				if err != nil {
+					return err
+				}
+				v.Base.ID = x
+			}
+		case "Created":
+			if null, err := d.Null(); err != nil {
+				return err
+			} else if !null {
+				x, err := d.ReadString()
+				if err != nil {
+					return err
+				}
+				v.Base.Created = x
+			}
+		case "name":
+			if null, err := d.Null(); err != nil {
+				return err
+			} else if !null {
+				x, err := d.ReadString()
+				if err != nil {
+					return err
+				}
+				v.Name = x
+			}
+		case "age":
+			if null, err := d.Null(); err != nil {
+				return err
+			} else if !null {
+				x, err := d.ReadInt(0)
+				if err != nil {
+					return err
+				}
+				v.Age = int(x)
+			}
+		case "score":
+			if null, err := d.Null(); err != nil {
+				return err
+			} else if !null {
+				x, err := d.ReadFloat(64)
+				if err != nil {
+					return err
+				}
+				v.Score = x
+			}
+		case "ratio":
+			if null, err := d.Null(); err != nil {
+				return err
+			} else if !null {
+				x, err := d.ReadFloat(32)
+				if err != nil {
+					return err
+				}
+				v.Ratio = float32(x)
+			}
+		case "active":
+			if null, err := d.Null(); err != nil {
+				return err
+			} else if !null {
+				s, err := d.ReadString()
+				if err != nil {
+					return err
+				}
+				x, err := strconv.ParseBool(s)
+				if err != nil {
+					return err
+				}
+				v.Active = x
+			}
+		case "role":
+			if null, err := d.Null(); err != nil {
+				return err
+			} else if !null {
+				x, err := d.ReadString()
+				if err != nil {
+					return err
+				}
+				v.Role = Role(x)
+			}
+		case "address":
+			if err := v.Address.DecodeLibJSON(d); err != nil {
+				return err
+			}
+		case "previous":
+			if null, err := d.Null(); err != nil {
+				return err
+			} else if null {
+				v.Previous = nil
+			} else {
+				if v.Previous == nil {
+					v.Previous = new(Address)
+				}
+				if err := v.Previous.DecodeLibJSON(d); err != nil {
+					return err
+				}
+			}
+		case "tags":
+			if null, err := d.Null(); err != nil {
+				return err
+			} else if null {
+				v.Tags = nil
+			} else {
+				if _, err := d.Expect(libjson.TokenArrayStart); err != nil {
+					return err
+				}
+				if v.Tags == nil {
+					v.Tags = []string{}
+				} else {
+					v.Tags = v.Tags[:0]
+				}
+				for d.More() {
+					var e1 string
+					if null, err := d.Null(); err != nil {
+						return err
+					} else if !null {
+						x, err := d.ReadString()
+						if err != nil {
+							return err
+						}
+						e1 = x
+					}
+					v.Tags = append(v.Tags, e1)
+				}
+				if _, err := d.Expect(libjson.TokenArrayEnd); err != nil {
+					return err
+				}
+			}
+		case "friends":
+			if null, err := d.Null(); err != nil {
+				return err
+			} else if null {
+				v.Friends = nil
+			} else {
+				if _, err := d.Expect(libjson.TokenArrayStart); err != nil {
+					return err
+				}
+				if v.Friends == nil {
+					v.Friends = []*Person{}
+				} else {
+					v.Friends = v.Friends[:0]
+				}
+				for d.More() {
+					var e2 *Person
+					if null, err := d.Null(); err != nil {
+						return err
+					} else if null {
+						e2 = nil
+					} else {
+						if e2 == nil {
+							e2 = new(Person)
+						}
+						if err := e2.DecodeLibJSON(d); err != nil {
+							return err
+						}
+					}
+					v.Friends = append(v.Friends, e2)
+				}
+				if _, err := d.Expect(libjson.TokenArrayEnd); err != nil {
+					return err
+				}
+			}
+		case "labels":
+			if null, err := d.Null(); err != nil {
+				return err
+			} else if null {
+				v.Labels = nil
+			} else {
+				if _, err := d.Expect(libjson.TokenObjectStart); err != nil {
+					return err
+				}
+				if v.Labels == nil {
+					v.Labels = make(map[string]string)
+				}
+				for d.More() {
+					k3, err := d.ReadKey()
+					if err != nil {
+						return err
+					}
+					var e4 string
+					if null, err := d.Null(); err != nil {
+						return err
+					} else if !null {
+						x, err := d.ReadString()
+						if err != nil {
+							return err
+						}
+						e4 = x
+					}
+					v.Labels[k3] = e4
+				}
+				if _, err := d.Expect(libjson.TokenObjectEnd); err != nil {
+					return err
+				}
+			}
+		case "roles":
+			if null, err := d.Null(); err != nil {
+				return err
+			} else if null {
+				v.Roles = nil
+			} else {
+				if _, err := d.Expect(libjson.TokenObjectStart); err != nil {
+					return err
+				}
+				if v.Roles == nil {
+					v.Roles = make(map[Role][]Address)
+				}
+				for d.More() {
+					k5, err := d.ReadKey()
+					if err != nil {
+						return err
+					}
+					var e6 []Address
+					if null, err := d.Null(); err != nil {
+						return err
+					} else if null {
+						e6 = nil
+					} else {
+						if _, err := d.Expect(libjson.TokenArrayStart); err != nil {
+							return err
+						}
+						if e6 == nil {
+							e6 = []Address{}
+						} else {
+							e6 = e6[:0]
+						}
+						for d.More() {
+							var e7 Address
+							if err := e7.DecodeLibJSON(d); err != nil {
+								return err
+							}
+							e6 = append(e6, e7)
+						}
+						if _, err := d.Expect(libjson.TokenArrayEnd); err != nil {
+							return err
+						}
+					}
+					v.Roles[Role(k5)] = e6
+				}
+				if _, err := d.Expect(libjson.TokenObjectEnd); err != nil {
+					return err
+				}
+			}
+		case "nested":
+			if null, err := d.Null(); err != nil {
+				return err
+			} else if null {
+				v.Nested = nil
+			} else {
+				if _, err := d.Expect(libjson.TokenArrayStart); err != nil {
+					return err
+				}
+				if v.Nested == nil {
+					v.Nested = [][]int8{}
+				} else {
+					v.Nested = v.Nested[:0]
+				}
+				for d.More() {
+					var e8 []int8
+					if null, err := d.Null(); err != nil {
+						return err
+					} else if null {
+						e8 = nil
+					} else {
+						if _, err := d.Expect(libjson.TokenArrayStart); err != nil {
+							return err
+						}
+						if e8 == nil {
+							e8 = []int8{}
+						} else {
+							e8 = e8[:0]
+						}
+						for d.More() {
+							var e9 int8
+							if null, err := d.Null(); err != nil {
+								return err
+							} else if !null {
+								x, err := d.ReadInt(8)
+								if err != nil {
+									return err
+								}
+								e9 = int8(x)
+							}
+							e8 = append(e8, e9)
+						}
+						if _, err := d.Expect(libjson.TokenArrayEnd); err != nil {
+							return err
+						}
+					}
+					v.Nested = append(v.Nested, e8)
+				}
+				if _, err := d.Expect(libjson.TokenArrayEnd); err != nil {
+					return err
+				}
+			}
+		case "nick":
+			if null, err := d.Null(); err != nil {
+				return err
+			} else if null {
+				v.Nick = nil
+			} else {
+				if v.Nick == nil {
+					v.Nick = new(*string)
+				}
+				if *v.Nick == nil {
+					*v.Nick = new(string)
+				}
+				x, err := d.ReadString()
+				if err != nil {
+					return err
+				}
+				**v.Nick = x
+			}
+		case "rank":
+			if null, err := d.Null(); err != nil {
+				return err
+			} else if null {
+				v.Rank = nil
+			} else {
+				if v.Rank == nil {
+					v.Rank = new(int64)
+				}
+				s, err := d.ReadString()
+				if err != nil {
+					return err
+				}
+				x, err := strconv.ParseInt(s, 10, 64)
+				if err != nil {
+					return err
+				}
+				*v.Rank = x
+			}
+		default:
+			if err := d.Skip(); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := d.Expect(libjson.TokenObjectEnd)
+	return err
+}
+
+// UnmarshalLibJSON decodes data into v
+func (v *Person) UnmarshalLibJSON(data []byte) error {
+	d := libjson.NewDecoderBytes(data)
+	if err := v.DecodeLibJSON(d); err != nil {
+		return err
+	}
+	return d.End()
+}
+
+// AppendLibJSON appends the json encoding of v to buf
+func (v *Person) AppendLibJSON(buf []byte) ([]byte, error) {
+	if v == nil {
+		return append(buf, "null"...), nil
+	}
+	var err error
+	// the comma preceding the first member is replaced by the opening brace
+	start := len(buf)
+
+	buf = append(buf, ",\"id\":"...)
+	buf = append(buf, '"')
+	buf = strconv.AppendInt(buf, v.Base.ID, 10)
+	buf = append(buf, '"')
+
+	buf = append(buf, ",\"Created\":"...)
+	buf = libjson.AppendString(buf, v.Base.Created)
+
+	buf = append(buf, ",\"name\":"...)
+	buf = libjson.AppendString(buf, v.Name)
+
+	if v.Age != 0 {
+		buf = append(buf, ",\"age\":"...)
+		buf = strconv.AppendInt(buf, int64(v.Age), 10)
+	}
+
+	buf = append(buf, ",\"score\":"...)
+	if buf, err = libjson.AppendFloat(buf, v.Score, 64); err != nil {
+		return nil, err
+	}
+
+	if v.Ratio != 0 {
+		buf = append(buf, ",\"ratio\":"...)
+		if buf, err = libjson.AppendFloat(buf, float64(v.Ratio), 32); err != nil {
+			return nil, err
+		}
+	}
+
+	buf = append(buf, ",\"active\":"...)
+	buf = append(buf, '"')
+	buf = strconv.AppendBool(buf, v.Active)
+	buf = append(buf, '"')
+
+	if v.Role != "" {
+		buf = append(buf, ",\"role\":"...)
+		buf = libjson.AppendString(buf, string(v.Role))
+	}
+
+	buf = append(buf, ",\"address\":"...)
+	if buf, err = v.Address.AppendLibJSON(buf); err != nil {
+		return nil, err
+	}
+
+	buf = append(buf, ",\"previous\":"...)
+	if v.Previous == nil {
+		buf = append(buf, "null"...)
+	} else {
+		if buf, err = v.Previous.AppendLibJSON(buf); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(v.Tags) != 0 {
+		buf = append(buf, ",\"tags\":"...)
+		if v.Tags == nil {
+			buf = append(buf, "null"...)
+		} else {
+			buf = append(buf, '[')
+			for i10 := range v.Tags {
+				if i10 > 0 {
+					buf = append(buf, ',')
+				}
+				buf = libjson.AppendString(buf, v.Tags[i10])
+			}
+			buf = append(buf, ']')
+		}
+	}
+
+	buf = append(buf, ",\"friends\":"...)
+	if v.Friends == nil {
+		buf = append(buf, "null"...)
+	} else {
+		buf = append(buf, '[')
+		for i11 := range v.Friends {
+			if i11 > 0 {
+				buf = append(buf, ',')
+			}
+			if v.Friends[i11] == nil {
+				buf = append(buf, "null"...)
+			} else {
+				if buf, err = v.Friends[i11].AppendLibJSON(buf); err != nil {
+					return nil, err
+				}
+			}
+		}
+		buf = append(buf, ']')
+	}
+
+	if len(v.Labels) != 0 {
+		buf = append(buf, ",\"labels\":"...)
+		if v.Labels == nil {
+			buf = append(buf, "null"...)
+		} else {
+			buf = append(buf, '{')
+			for i12, k13 := range slices.Sorted(maps.Keys(v.Labels)) {
+				if i12 > 0 {
+					buf = append(buf, ',')
+				}
+				buf = libjson.AppendString(buf, k13)
+				buf = append(buf, ':')
+				e14 := v.Labels[k13]
+				buf = libjson.AppendString(buf, e14)
+			}
+			buf = append(buf, '}')
+		}
+	}
+
+	if len(v.Roles) != 0 {
+		buf = append(buf, ",\"roles\":"...)
+		if v.Roles == nil {
+			buf = append(buf, "null"...)
+		} else {
+			buf = append(buf, '{')
+			for i15, k16 := range slices.Sorted(maps.Keys(v.Roles)) {
+				if i15 > 0 {
+					buf = append(buf, ',')
+				}
+				buf = libjson.AppendString(buf, string(k16))
+				buf = append(buf, ':')
+				e17 := v.Roles[k16]
+				if e17 == nil {
+					buf = append(buf, "null"...)
+				} else {
+					buf = append(buf, '[')
+					for i18 := range e17 {
+						if i18 > 0 {
+							buf = append(buf, ',')
+						}
+						if buf, err = e17[i18].AppendLibJSON(buf); err != nil {
+							return nil, err
+						}
+					}
+					buf = append(buf, ']')
+				}
+			}
+			buf = append(buf, '}')
+		}
+	}
+
+	if len(v.Nested) != 0 {
+		buf = append(buf, ",\"nested\":"...)
+		if v.Nested == nil {
+			buf = append(buf, "null"...)
+		} else {
+			buf = append(buf, '[')
+			for i19 := range v.Nested {
+				if i19 > 0 {
+					buf = append(buf, ',')
+				}
+				if v.Nested[i19] == nil {
+					buf = append(buf, "null"...)
+				} else {
+					buf = append(buf, '[')
+					for i20 := range v.Nested[i19] {
+						if i20 > 0 {
+							buf = append(buf, ',')
+						}
+						buf = strconv.AppendInt(buf, int64(v.Nested[i19][i20]), 10)
+					}
+					buf = append(buf, ']')
+				}
+			}
+			buf = append(buf, ']')
+		}
+	}
+
+	if v.Nick != nil {
+		buf = append(buf, ",\"nick\":"...)
+		if v.Nick == nil {
+			buf = append(buf, "null"...)
+		} else {
+			if *v.Nick == nil {
+				buf = append(buf, "null"...)
+			} else {
+				buf = libjson.AppendString(buf, **v.Nick)
+			}
+		}
+	}
+
+	buf = append(buf, ",\"rank\":"...)
+	if v.Rank == nil {
+		buf = append(buf, "null"...)
+	} else {
+		buf = append(buf, '"')
+		buf = strconv.AppendInt(buf, *v.Rank, 10)
+		buf = append(buf, '"')
+	}
+	if len(buf) == start {
+		return append(buf, "{}"...), nil
+	}
+	buf[start] = '{'
+	return append(buf, '}'), nil
+}
+
+// MarshalLibJSON returns the json encoding of v
+func (v *Person) MarshalLibJSON() ([]byte, error) {
+	return v.AppendLibJSON(nil)
+}
+
+// DecodeLibJSON decodes the next value of d into v, null leaves v unchanged
+func (v *Address) DecodeLibJSON(d *libjson.Decoder) error {
+	if null, err := d.Null(); err != nil || null {
+		return err
+	}
+	if _, err := d.Expect(libjson.TokenObjectStart); err != nil {
+		return err
+	}
+	for d.More() {
+		key, err := d.ReadKeyBytes()
+		if err != nil {
+			return err
+		}
+		switch string(key) {
+		case "street":
+			if null, err := d.Null(); err != nil {
+				return err
+			} else if !null {
+				x, err := d.ReadString()
+				if err != nil {
+					return err
+				}
+				v.Street = x
+			}
+		case "zip":
+			if null, err := d.Null(); err != nil {
+				return err
+			} else if !null {
+				x, err := d.ReadUint(16)
+				if err != nil {
+					return err
+				}
+				v.Zip = uint16(x)
+			}
+		default:
+			if err := d.Skip(); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := d.Expect(libjson.TokenObjectEnd)
+	return err
+}
+
+// UnmarshalLibJSON decodes data into v
+func (v *Address) UnmarshalLibJSON(data []byte) error {
+	d := libjson.NewDecoderBytes(data)
+	if err := v.DecodeLibJSON(d); err != nil {
+		return err
+	}
+	return d.End()
+}
+
+// AppendLibJSON appends the json encoding of v to buf
+func (v *Address) AppendLibJSON(buf []byte) ([]byte, error) {
+	if v == nil {
+		return append(buf, "null"...), nil
+	}
+	// the comma preceding the first member is replaced by the opening brace
+	start := len(buf)
+
+	buf = append(buf, ",\"street\":"...)
+	buf = libjson.AppendString(buf, v.Street)
+
+	if v.Zip != 0 {
+		buf = append(buf, ",\"zip\":"...)
+		buf = strconv.AppendUint(buf, uint64(v.Zip), 10)
+	}
+	if len(buf) == start {
+		return append(buf, "{}"...), nil
+	}
+	buf[start] = '{'
+	return append(buf, '}'), nil
+}
+
+// MarshalLibJSON returns the json encoding of v
+func (v *Address) MarshalLibJSON() ([]byte, error) {
+	return v.AppendLibJSON(nil)
+}
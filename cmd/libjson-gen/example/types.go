@@ -0,0 +1,38 @@
+// Package example holds types used for testing the code generated by
+// libjson-gen
+package example
+
+//go:generate go run github.com/xnacly/libjson/cmd/libjson-gen -type Person -output person_libjson.go
+
+type Role string
+
+type Base struct {
+	ID      int64 `json:"id,string"`
+	Created string
+}
+
+type Address struct {
+	Street string `json:"street"`
+	Zip    uint16 `json:"zip,omitempty"`
+}
+
+type Person struct {
+	Base
+	Name     string             `json:"name"`
+	Age      int                `json:"age,omitempty"`
+	Score    float64            `json:"score"`
+	Ratio    float32            `json:"ratio,omitempty"`
+	Active   bool               `json:"active,string"`
+	Role     Role               `json:"role,omitempty"`
+	Address  Address            `json:"address"`
+	Previous *Address           `json:"previous"`
+	Tags     []string           `json:"tags,omitempty"`
+	Friends  []*Person          `json:"friends"`
+	Labels   map[string]string  `json:"labels,omitempty"`
+	Roles    map[Role][]Address `json:"roles,omitempty"`
+	Nested   [][]int8           `json:"nested,omitempty"`
+	Nick     **string           `json:"nick,omitempty"`
+	Rank     *int64             `json:"rank,string"`
+	Ignored  string             `json:"-"`
+	private  string
+}
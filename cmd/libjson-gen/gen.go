@@ -0,0 +1,647 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/xnacly/libjson"
+)
+
+type kind int
+
+const (
+	kindString kind = iota
+	kindBool
+	kindInt
+	kindUint
+	kindFloat
+	// struct type of the package, its methods are generated as well
+	kindStruct
+	// type of another package, has to implement the generated methods itself
+	kindExternal
+	kindPointer
+	kindSlice
+	kindMap
+)
+
+// typeInfo describes the type of a field, resolved from its declaration
+type typeInfo struct {
+	kind kind
+	// go source of the type, such as Kind, []string or *Address
+	name string
+	// bit size of numbers, 0 for int and uint, see strconv.ParseInt
+	bits int
+	// element type of pointers, slices and maps
+	elem *typeInfo
+	// key type of maps
+	key *typeInfo
+}
+
+// field is a struct field encoded as an object member
+type field struct {
+	// key of the member
+	key string
+	// go expression selecting the field of v, includes embedded structs
+	access    string
+	typ       *typeInfo
+	omitempty bool
+	// encode numbers and bools as strings, see the string option of
+	// encoding/json
+	quoted bool
+	// amount of embedded structs the field is promoted from
+	depth int
+}
+
+type generator struct {
+	fset *token.FileSet
+	pkg  string
+	// type declarations of the package by name
+	decls map[string]*ast.TypeSpec
+	// import paths of the package by name, used for types of other packages
+	imports map[string]string
+	// struct types to generate methods for, in order of their first use
+	queue []string
+	// imports of the generated code by name
+	uses map[string]string
+	// number of temporary variables emitted
+	tmp int
+	out bytes.Buffer
+}
+
+// generate returns the source of the methods of types and all struct types
+// of the package they use, reading the package from dir. The file named
+// output is not read, since it holds previously generated code.
+func generate(dir string, output string, types []string) ([]byte, error) {
+	g := &generator{
+		fset:    token.NewFileSet(),
+		decls:   map[string]*ast.TypeSpec{},
+		imports: map[string]string{},
+		uses:    map[string]string{"libjson": "github.com/xnacly/libjson"},
+	}
+	if err := g.load(dir, output); err != nil {
+		return nil, err
+	}
+	for _, name := range types {
+		spec, ok := g.decls[name]
+		if !ok {
+			return nil, fmt.Errorf("type %s not found in %s", name, dir)
+		}
+		if _, ok := spec.Type.(*ast.StructType); !ok {
+			return nil, fmt.Errorf("type %s is not a struct", name)
+		}
+		g.enqueue(name)
+	}
+
+	var body bytes.Buffer
+	for i := 0; i < len(g.queue); i++ {
+		if err := g.generate(g.queue[i]); err != nil {
+			return nil, err
+		}
+		body.Write(g.out.Bytes())
+		g.out.Reset()
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by libjson-gen; DO NOT EDIT.\n\npackage %s\n\nimport (\n", g.pkg)
+	names := make([]string, 0, len(g.uses))
+	for name := range g.uses {
+		names = append(names, name)
+	}
+	slices.SortFunc(names, func(a, b string) int { return strings.Compare(g.uses[a], g.uses[b]) })
+	// the standard library is imported first, separated by a blank line
+	for _, std := range []bool{true, false} {
+		if !std {
+			src.WriteString("\n")
+		}
+		for _, name := range names {
+			p := g.uses[name]
+			if first, _, _ := strings.Cut(p, "/"); strings.Contains(first, ".") == std {
+				continue
+			}
+			if path.Base(p) == name {
+				fmt.Fprintf(&src, "%q\n", p)
+			} else {
+				fmt.Fprintf(&src, "%s %q\n", name, p)
+			}
+		}
+	}
+	src.WriteString(")\n")
+	src.Write(body.Bytes())
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+	return formatted, nil
+}
+
+// load collects the type declarations and imports of the package in dir
+func (g *generator) load(dir string, output string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") || name == output {
+			continue
+		}
+		f, err := parser.ParseFile(g.fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return err
+		}
+		if g.pkg == "" {
+			g.pkg = f.Name.Name
+		} else if g.pkg != f.Name.Name {
+			return fmt.Errorf("found packages %s and %s in %s", g.pkg, f.Name.Name, dir)
+		}
+		for _, imp := range f.Imports {
+			p, _ := strconv.Unquote(imp.Path.Value)
+			name := path.Base(p)
+			if imp.Name != nil {
+				name = imp.Name.Name
+			}
+			g.imports[name] = p
+		}
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				spec := spec.(*ast.TypeSpec)
+				g.decls[spec.Name.Name] = spec
+			}
+		}
+	}
+	if g.pkg == "" {
+		return fmt.Errorf("no go files found in %s", dir)
+	}
+	return nil
+}
+
+func (g *generator) enqueue(name string) {
+	if !slices.Contains(g.queue, name) {
+		g.queue = append(g.queue, name)
+	}
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.out, format, args...)
+}
+
+// temp returns a new variable name starting with prefix
+func (g *generator) temp(prefix string) string {
+	g.tmp++
+	return prefix + strconv.Itoa(g.tmp)
+}
+
+func (g *generator) unsupported(expr ast.Expr) error {
+	return fmt.Errorf("%s: unsupported type %s", g.fset.Position(expr.Pos()), exprString(expr))
+}
+
+// exprString formats expr as go source
+func exprString(expr ast.Expr) string {
+	var b bytes.Buffer
+	format.Node(&b, token.NewFileSet(), expr)
+	return b.String()
+}
+
+var basic = map[string]typeInfo{
+	"string":  {kind: kindString},
+	"bool":    {kind: kindBool},
+	"int":     {kind: kindInt},
+	"int8":    {kind: kindInt, bits: 8},
+	"int16":   {kind: kindInt, bits: 16},
+	"int32":   {kind: kindInt, bits: 32},
+	"rune":    {kind: kindInt, bits: 32},
+	"int64":   {kind: kindInt, bits: 64},
+	"uint":    {kind: kindUint},
+	"uint8":   {kind: kindUint, bits: 8},
+	"byte":    {kind: kindUint, bits: 8},
+	"uint16":  {kind: kindUint, bits: 16},
+	"uint32":  {kind: kindUint, bits: 32},
+	"uint64":  {kind: kindUint, bits: 64},
+	"float32": {kind: kindFloat, bits: 32},
+	"float64": {kind: kindFloat, bits: 64},
+}
+
+// resolve determines the typeInfo of expr, enqueueing the struct types of
+// the package it uses
+func (g *generator) resolve(expr ast.Expr) (*typeInfo, error) {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if t, ok := basic[e.Name]; ok {
+			t.name = e.Name
+			return &t, nil
+		}
+		spec, ok := g.decls[e.Name]
+		if !ok || spec.TypeParams != nil {
+			return nil, g.unsupported(expr)
+		}
+		if _, ok := spec.Type.(*ast.StructType); ok {
+			g.enqueue(e.Name)
+			return &typeInfo{kind: kindStruct, name: e.Name}, nil
+		}
+		under, err := g.resolve(spec.Type)
+		if err != nil {
+			return nil, err
+		}
+		if spec.Assign.IsValid() {
+			return under, nil
+		}
+		t := *under
+		t.name = e.Name
+		return &t, nil
+	case *ast.StarExpr:
+		elem, err := g.resolve(e.X)
+		if err != nil {
+			return nil, err
+		}
+		return &typeInfo{kind: kindPointer, name: "*" + elem.name, elem: elem}, nil
+	case *ast.ArrayType:
+		if e.Len != nil {
+			return nil, g.unsupported(expr)
+		}
+		elem, err := g.resolve(e.Elt)
+		if err != nil {
+			return nil, err
+		}
+		return &typeInfo{kind: kindSlice, name: "[]" + elem.name, elem: elem}, nil
+	case *ast.MapType:
+		key, err := g.resolve(e.Key)
+		if err != nil {
+			return nil, err
+		}
+		if key.kind != kindString {
+			return nil, fmt.Errorf("%s: unsupported map key type %s, only strings are supported", g.fset.Position(e.Key.Pos()), key.name)
+		}
+		elem, err := g.resolve(e.Value)
+		if err != nil {
+			return nil, err
+		}
+		return &typeInfo{kind: kindMap, name: "map[" + key.name + "]" + elem.name, key: key, elem: elem}, nil
+	case *ast.SelectorExpr:
+		pkg, ok := e.X.(*ast.Ident)
+		if !ok {
+			return nil, g.unsupported(expr)
+		}
+		p, ok := g.imports[pkg.Name]
+		if !ok {
+			return nil, g.unsupported(expr)
+		}
+		g.uses[pkg.Name] = p
+		return &typeInfo{kind: kindExternal, name: pkg.Name + "." + e.Sel.Name}, nil
+	default:
+		return nil, g.unsupported(expr)
+	}
+}
+
+// fields returns the encoded fields of st in declaration order, fields of
+// embedded structs are promoted in place of the embedded struct. Of multiple
+// fields with the same key, the least nested one is used, as for
+// encoding/json.
+func (g *generator) fields(st *ast.StructType) ([]field, error) {
+	all, err := g.collect(st, "v.", 0)
+	if err != nil {
+		return nil, err
+	}
+	depths := map[string]int{}
+	for _, f := range all {
+		if d, ok := depths[f.key]; !ok || f.depth < d {
+			depths[f.key] = f.depth
+		}
+	}
+	fields := make([]field, 0, len(all))
+	for _, f := range all {
+		if depths[f.key] == f.depth {
+			fields = append(fields, f)
+			// following fields with the same key are dropped
+			depths[f.key] = -1
+		}
+	}
+	return fields, nil
+}
+
+// collect returns all fields of st and its embedded structs
+func (g *generator) collect(st *ast.StructType, prefix string, depth int) ([]field, error) {
+	var fields []field
+	for _, f := range st.Fields.List {
+		tag := ""
+		if f.Tag != nil {
+			raw, _ := strconv.Unquote(f.Tag.Value)
+			tag = reflect.StructTag(raw).Get("json")
+		}
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if len(f.Names) == 0 && name == "" {
+			ident, ok := f.Type.(*ast.Ident)
+			if !ok {
+				return nil, fmt.Errorf("%s: unsupported embedded field %s", g.fset.Position(f.Pos()), exprString(f.Type))
+			}
+			if spec, ok := g.decls[ident.Name]; ok {
+				if s, ok := spec.Type.(*ast.StructType); ok {
+					promoted, err := g.collect(s, prefix+ident.Name+".", depth+1)
+					if err != nil {
+						return nil, err
+					}
+					fields = append(fields, promoted...)
+					continue
+				}
+			}
+		}
+
+		names := make([]string, 0, len(f.Names))
+		for _, n := range f.Names {
+			names = append(names, n.Name)
+		}
+		if len(names) == 0 {
+			names = append(names, exprString(f.Type))
+		}
+		for _, n := range names {
+			if !ast.IsExported(n) {
+				continue
+			}
+			t, err := g.resolve(f.Type)
+			if err != nil {
+				return nil, err
+			}
+			fd := field{key: name, access: prefix + n, typ: t, depth: depth}
+			if fd.key == "" {
+				fd.key = n
+			}
+			for _, opt := range strings.Split(opts, ",") {
+				switch opt {
+				case "omitempty":
+					fd.omitempty = true
+				case "string":
+					// only applies to numbers and bools and pointers to
+					// these, as for encoding/json
+					elem := t
+					if elem.kind == kindPointer {
+						elem = elem.elem
+					}
+					switch elem.kind {
+					case kindInt, kindUint, kindFloat, kindBool:
+						fd.quoted = true
+					}
+				}
+			}
+			fields = append(fields, fd)
+		}
+	}
+	return fields, nil
+}
+
+func (g *generator) generate(name string) error {
+	st := g.decls[name].Type.(*ast.StructType)
+	fields, err := g.fields(st)
+	if err != nil {
+		return err
+	}
+
+	g.printf("\n// DecodeLibJSON decodes the next value of d into v, null leaves v unchanged\n")
+	g.printf("func (v *%s) DecodeLibJSON(d *libjson.Decoder) error {\n", name)
+	g.printf("if null, err := d.Null(); err != nil || null {\nreturn err\n}\n")
+	g.printf("if _, err := d.Expect(libjson.TokenObjectStart); err != nil {\nreturn err\n}\n")
+	g.printf("for d.More() {\nkey, err := d.ReadKeyBytes()\nif err != nil {\nreturn err\n}\n")
+	g.printf("switch string(key) {\n")
+	for _, f := range fields {
+		g.printf("case %q:\n", f.key)
+		g.decode(f.access, f.typ, f.quoted)
+	}
+	g.printf("default:\nif err := d.Skip(); err != nil {\nreturn err\n}\n}\n}\n")
+	g.printf("_, err := d.Expect(libjson.TokenObjectEnd)\nreturn err\n}\n")
+
+	g.printf("\n// UnmarshalLibJSON decodes data into v\n")
+	g.printf("func (v *%s) UnmarshalLibJSON(data []byte) error {\n", name)
+	g.printf("d := libjson.NewDecoderBytes(data)\n")
+	g.printf("if err := v.DecodeLibJSON(d); err != nil {\nreturn err\n}\nreturn d.End()\n}\n")
+
+	// the body is emitted first, since err is only declared if used
+	body := g.out.Len()
+	errUsed := false
+	for _, f := range fields {
+		g.printf("\n")
+		if f.omitempty {
+			if cond := nonEmpty(f.access, f.typ); cond != "" {
+				g.printf("if %s {\n", cond)
+			}
+		}
+		lit := string(libjson.AppendString([]byte{','}, f.key)) + ":"
+		g.printf("buf = append(buf, %s...)\n", strconv.Quote(lit))
+		errUsed = g.encode(f.access, f.typ, f.quoted) || errUsed
+		if f.omitempty && nonEmpty(f.access, f.typ) != "" {
+			g.printf("}\n")
+		}
+	}
+	encoded := slices.Clone(g.out.Bytes()[body:])
+	g.out.Truncate(body)
+
+	g.printf("\n// AppendLibJSON appends the json encoding of v to buf\n")
+	g.printf("func (v *%s) AppendLibJSON(buf []byte) ([]byte, error) {\n", name)
+	g.printf("if v == nil {\nreturn append(buf, \"null\"...), nil\n}\n")
+	if errUsed {
+		g.printf("var err error\n")
+	}
+	g.printf("// the comma preceding the first member is replaced by the opening brace\nstart := len(buf)\n")
+	g.out.Write(encoded)
+	g.printf("if len(buf) == start {\nreturn append(buf, \"{}\"...), nil\n}\n")
+	g.printf("buf[start] = '{'\nreturn append(buf, '}'), nil\n}\n")
+
+	g.printf("\n// MarshalLibJSON returns the json encoding of v\n")
+	g.printf("func (v *%s) MarshalLibJSON() ([]byte, error) {\nreturn v.AppendLibJSON(nil)\n}\n", name)
+	return nil
+}
+
+// paren wraps dereferences, thus expr can be indexed or have its methods
+// called
+func paren(expr string) string {
+	if strings.HasPrefix(expr, "*") {
+		return "(" + expr + ")"
+	}
+	return expr
+}
+
+// convert converts expr of type from to t, omitting the conversion if the
+// types are identical
+func convert(expr string, from string, t *typeInfo) string {
+	if t.name == from {
+		return expr
+	}
+	return t.name + "(" + expr + ")"
+}
+
+// decode emits the statements decoding the next value into target, null
+// leaves target unchanged, except for pointers, slices and maps, which are
+// set to nil
+func (g *generator) decode(target string, t *typeInfo, quoted bool) {
+	switch t.kind {
+	case kindStruct, kindExternal:
+		// handles null itself
+		g.decodeValue(target, t, quoted)
+		return
+	case kindPointer, kindSlice, kindMap:
+		g.printf("if null, err := d.Null(); err != nil {\nreturn err\n} else if null {\n%s = nil\n} else {\n", target)
+	default:
+		g.printf("if null, err := d.Null(); err != nil {\nreturn err\n} else if !null {\n")
+	}
+	g.decodeValue(target, t, quoted)
+	g.printf("}\n")
+}
+
+// readers maps kinds to the Decoder method reading them, their result type
+// and the strconv function for parsing quoted values
+var readers = map[kind][3]string{
+	kindString: {"ReadString", "string", ""},
+	kindBool:   {"ReadBool", "bool", "ParseBool"},
+	kindInt:    {"ReadInt", "int64", "ParseInt"},
+	kindUint:   {"ReadUint", "uint64", "ParseUint"},
+	kindFloat:  {"ReadFloat", "float64", "ParseFloat"},
+}
+
+// decodeValue emits the statements decoding the next value, which is not
+// null, into target
+func (g *generator) decodeValue(target string, t *typeInfo, quoted bool) {
+	switch t.kind {
+	case kindString, kindBool, kindInt, kindUint, kindFloat:
+		r := readers[t.kind]
+		bits := ""
+		if t.kind != kindString && t.kind != kindBool {
+			bits = strconv.Itoa(t.bits)
+		}
+		if quoted {
+			g.uses["strconv"] = "strconv"
+			args := ""
+			switch t.kind {
+			case kindInt, kindUint:
+				args = ", 10, " + bits
+			case kindFloat:
+				args = ", " + bits
+			}
+			g.printf("s, err := d.ReadString()\nif err != nil {\nreturn err\n}\n")
+			g.printf("x, err := strconv.%s(s%s)\n", r[2], args)
+		} else {
+			g.printf("x, err := d.%s(%s)\n", r[0], bits)
+		}
+		g.printf("if err != nil {\nreturn err\n}\n%s = %s\n", target, convert("x", r[1], t))
+	case kindStruct, kindExternal:
+		g.printf("if err := %s.DecodeLibJSON(d); err != nil {\nreturn err\n}\n", paren(target))
+	case kindPointer:
+		g.printf("if %s == nil {\n%s = new(%s)\n}\n", target, target, t.elem.name)
+		if t.elem.kind == kindStruct || t.elem.kind == kindExternal {
+			// the methods are declared on the pointer
+			g.decodeValue(target, t.elem, quoted)
+		} else {
+			g.decodeValue("*"+target, t.elem, quoted)
+		}
+	case kindSlice:
+		e := g.temp("e")
+		g.printf("if _, err := d.Expect(libjson.TokenArrayStart); err != nil {\nreturn err\n}\n")
+		g.printf("if %s == nil {\n%s = %s{}\n} else {\n%s = %s[:0]\n}\n", target, target, t.name, target, paren(target))
+		g.printf("for d.More() {\nvar %s %s\n", e, t.elem.name)
+		g.decode(e, t.elem, quoted)
+		g.printf("%s = append(%s, %s)\n}\n", target, target, e)
+		g.printf("if _, err := d.Expect(libjson.TokenArrayEnd); err != nil {\nreturn err\n}\n")
+	case kindMap:
+		k, e := g.temp("k"), g.temp("e")
+		g.printf("if _, err := d.Expect(libjson.TokenObjectStart); err != nil {\nreturn err\n}\n")
+		g.printf("if %s == nil {\n%s = make(%s)\n}\n", target, target, t.name)
+		g.printf("for d.More() {\n%s, err := d.ReadKey()\nif err != nil {\nreturn err\n}\nvar %s %s\n", k, e, t.elem.name)
+		g.decode(e, t.elem, quoted)
+		g.printf("%s[%s] = %s\n}\n", paren(target), convert(k, "string", t.key), e)
+		g.printf("if _, err := d.Expect(libjson.TokenObjectEnd); err != nil {\nreturn err\n}\n")
+	}
+}
+
+// nonEmpty returns the condition under which expr is not omitted via
+// omitempty, structs are never omitted
+func nonEmpty(expr string, t *typeInfo) string {
+	switch t.kind {
+	case kindString:
+		return expr + ` != ""`
+	case kindBool:
+		return expr
+	case kindInt, kindUint, kindFloat:
+		return expr + " != 0"
+	case kindPointer:
+		return expr + " != nil"
+	case kindSlice, kindMap:
+		return "len(" + expr + ") != 0"
+	default:
+		return ""
+	}
+}
+
+// encode emits the statements appending expr to buf, reports whether the
+// emitted code uses err
+func (g *generator) encode(expr string, t *typeInfo, quoted bool) bool {
+	errUsed := false
+	// nil pointers are encoded as null, thus the quotes are added by the
+	// element
+	quote := quoted && t.kind != kindPointer
+	if quote {
+		g.printf("buf = append(buf, '\"')\n")
+	}
+	switch t.kind {
+	case kindString:
+		g.printf("buf = libjson.AppendString(buf, %s)\n", convert(expr, t.name, &typeInfo{name: "string"}))
+	case kindBool:
+		g.uses["strconv"] = "strconv"
+		g.printf("buf = strconv.AppendBool(buf, %s)\n", convert(expr, t.name, &typeInfo{name: "bool"}))
+	case kindInt:
+		g.uses["strconv"] = "strconv"
+		g.printf("buf = strconv.AppendInt(buf, %s, 10)\n", convert(expr, t.name, &typeInfo{name: "int64"}))
+	case kindUint:
+		g.uses["strconv"] = "strconv"
+		g.printf("buf = strconv.AppendUint(buf, %s, 10)\n", convert(expr, t.name, &typeInfo{name: "uint64"}))
+	case kindFloat:
+		g.printf("if buf, err = libjson.AppendFloat(buf, %s, %d); err != nil {\nreturn nil, err\n}\n", convert(expr, t.name, &typeInfo{name: "float64"}), t.bits)
+		errUsed = true
+	case kindStruct, kindExternal:
+		g.printf("if buf, err = %s.AppendLibJSON(buf); err != nil {\nreturn nil, err\n}\n", paren(expr))
+		errUsed = true
+	case kindPointer:
+		g.printf("if %s == nil {\nbuf = append(buf, \"null\"...)\n} else {\n", expr)
+		if t.elem.kind == kindStruct || t.elem.kind == kindExternal {
+			errUsed = g.encode(expr, t.elem, false)
+		} else {
+			errUsed = g.encode("*"+expr, t.elem, quoted)
+		}
+		g.printf("}\n")
+	case kindSlice:
+		i := g.temp("i")
+		g.printf("if %s == nil {\nbuf = append(buf, \"null\"...)\n} else {\nbuf = append(buf, '[')\n", expr)
+		g.printf("for %s := range %s {\nif %s > 0 {\nbuf = append(buf, ',')\n}\n", i, expr, i)
+		errUsed = g.encode(paren(expr)+"["+i+"]", t.elem, quoted)
+		g.printf("}\nbuf = append(buf, ']')\n}\n")
+	case kindMap:
+		g.uses["maps"] = "maps"
+		g.uses["slices"] = "slices"
+		i, k, e := g.temp("i"), g.temp("k"), g.temp("e")
+		g.printf("if %s == nil {\nbuf = append(buf, \"null\"...)\n} else {\nbuf = append(buf, '{')\n", expr)
+		g.printf("for %s, %s := range slices.Sorted(maps.Keys(%s)) {\nif %s > 0 {\nbuf = append(buf, ',')\n}\n", i, k, expr, i)
+		g.printf("buf = libjson.AppendString(buf, %s)\nbuf = append(buf, ':')\n", convert(k, t.key.name, &typeInfo{name: "string"}))
+		// map elements are not addressable, thus they are copied
+		g.printf("%s := %s[%s]\n", e, paren(expr), k)
+		errUsed = g.encode(e, t.elem, quoted)
+		g.printf("}\nbuf = append(buf, '}')\n}\n")
+	}
+	if quote {
+		g.printf("buf = append(buf, '\"')\n")
+	}
+	return errUsed
+}
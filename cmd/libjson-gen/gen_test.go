@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// the committed code of the example package has to match the output of the
+// generator, regenerate via go generate ./...
+func TestGenerateExample(t *testing.T) {
+	want, err := os.ReadFile("example/person_libjson.go")
+	assert.NoError(t, err)
+	got, err := generate("example", "person_libjson.go", []string{"Person"})
+	assert.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+func TestGenerateFail(t *testing.T) {
+	input := map[string]string{
+		"missing":       `type Other struct{}`,
+		"not a struct":  `type T int`,
+		"interface":     `type T struct { A any }`,
+		"array":         `type T struct { A [2]int }`,
+		"int map key":   `type T struct { A map[int]string }`,
+		"embedded ptr":  `type E struct{}; type T struct { *E }`,
+		"channel":       `type T struct { A chan int }`,
+		"unknown ident": `type T struct { A Unknown }`,
+	}
+	for name, src := range input {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			err := os.WriteFile(filepath.Join(dir, "t.go"), []byte("package t\n"+src+"\n"), 0o644)
+			assert.NoError(t, err)
+			_, err = generate(dir, "t_libjson.go", []string{"T"})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestOutputPath(t *testing.T) {
+	abs := filepath.Join(t.TempDir(), "x.go")
+	input := []struct {
+		dir, output string
+		name, skip  string
+	}{
+		{"example", "", filepath.Join("example", "person_libjson.go"), "person_libjson.go"},
+		{"example", "gen.go", filepath.Join("example", "gen.go"), "gen.go"},
+		{"example", filepath.Join("sub", "gen.go"), filepath.Join("example", "sub", "gen.go"), ""},
+		{".", abs, abs, ""},
+	}
+	for _, i := range input {
+		t.Run(i.output, func(t *testing.T) {
+			name, skip := outputPath(i.dir, i.output, []string{"Person"})
+			assert.Equal(t, i.name, name)
+			assert.Equal(t, i.skip, skip)
+		})
+	}
+
+	dir, err := filepath.Abs("example")
+	assert.NoError(t, err)
+	name, skip := outputPath("example", filepath.Join(dir, "gen.go"), []string{"Person"})
+	assert.Equal(t, filepath.Join(dir, "gen.go"), name)
+	assert.Equal(t, "gen.go", skip)
+}
+
+func TestGenerateQuotedPointer(t *testing.T) {
+	dir := t.TempDir()
+	src := "package t\ntype T struct {\n\tA *int64 `json:\"a,string\"`\n\tB *string `json:\"b,string\"`\n}\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "t.go"), []byte(src), 0o644))
+	got, err := generate(dir, "t_libjson.go", []string{"T"})
+	assert.NoError(t, err)
+	// the option applies to the element of pointers to numbers and bools
+	assert.Contains(t, string(got), "x, err := strconv.ParseInt(s, 10, 64)")
+	// strings are not quoted twice
+	assert.Equal(t, 2, strings.Count(string(got), `buf = append(buf, '"')`))
+}
@@ -0,0 +1,82 @@
+// libjson-gen generates reflection free json decoding and encoding methods
+// for struct types, driven directly by the tokens of a libjson.Decoder:
+//
+//	//go:generate go run github.com/xnacly/libjson/cmd/libjson-gen -type Person,Address
+//
+// For every struct type, including struct types of the same package used by
+// its fields, the following methods are generated:
+//
+//	func (v *T) DecodeLibJSON(d *libjson.Decoder) error
+//	func (v *T) UnmarshalLibJSON(data []byte) error
+//	func (v *T) AppendLibJSON(buf []byte) ([]byte, error)
+//	func (v *T) MarshalLibJSON() ([]byte, error)
+//
+// Fields are named and configured via `json:"name,omitempty,string"` tags as
+// for encoding/json. Supported field types are strings, bools, integers,
+// floats, structs, pointers, slices and maps with string keys, as well as
+// named types of these. Struct types of other packages have to provide
+// DecodeLibJSON and AppendLibJSON themselves. Unlike encoding/json, object
+// keys are matched case sensitive and unknown keys are skipped.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("libjson-gen: ")
+	typeNames := flag.String("type", "", "comma separated list of struct type names, required")
+	output := flag.String("output", "", "output file name, defaults to <first type>_libjson.go")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: libjson-gen -type T[,T...] [-output file] [directory]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *typeNames == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+	types := strings.Split(*typeNames, ",")
+	name, skip := outputPath(dir, *output, types)
+
+	src, err := generate(dir, skip, types)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if err := os.WriteFile(name, src, 0o644); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// outputPath returns the file to write the code for types in dir to, a
+// relative output is relative to dir. skip is the base name of the file if it
+// is in dir, the previously generated code must not be parsed.
+func outputPath(dir string, output string, types []string) (name string, skip string) {
+	name = output
+	if name == "" {
+		name = strings.ToLower(types[0]) + "_libjson.go"
+	}
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(dir, name)
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return name, filepath.Base(name)
+	}
+	absName, err := filepath.Abs(name)
+	if err != nil || filepath.Dir(absName) != absDir {
+		return name, ""
+	}
+	return name, filepath.Base(name)
+}
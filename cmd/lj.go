@@ -1,7 +1,12 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 
@@ -17,6 +22,13 @@ func Must[T any](t T, err error) T {
 
 func main() {
 	args := os.Args
+	if len(args) > 1 && args[1] == "infer" {
+		infer(args[2:])
+		return
+	} else if len(args) > 1 && args[1] == "gen" {
+		gen(args[2:])
+		return
+	}
 	var file *os.File
 	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice != 0 { // we are in a pipe
 		if len(args) == 1 {
@@ -30,3 +42,66 @@ func main() {
 	json := Must(libjson.NewReader(file))
 	fmt.Printf("%+#v\n", Must(libjson.Get[any](&json, query)))
 }
+
+// infer implements `lj infer [-schema] [file...]`, summarizing the structure
+// of all values in the files or stdin
+func infer(args []string) {
+	flags := flag.NewFlagSet("infer", flag.ExitOnError)
+	schema := flags.Bool("schema", false, "print a JSON Schema instead of a summary")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: lj infer [-schema] [file...]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	docs := samples(flags.Args())
+	if !*schema {
+		fmt.Print(libjson.Summarize(docs...))
+		return
+	}
+	s := libjson.InferSchema(docs...)
+	buf := bytes.Buffer{}
+	if err := json.Indent(&buf, Must(s.Append(nil)), "", "  "); err != nil {
+		log.Fatalln(err)
+	}
+	fmt.Println(buf.String())
+}
+
+// gen implements `lj gen [-type name] [file...]`, printing Go type
+// declarations for all values in the files or stdin
+func gen(args []string) {
+	flags := flag.NewFlagSet("gen", flag.ExitOnError)
+	name := flags.String("type", "T", "name of the top level type")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: lj gen [-type name] [file...]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	os.Stdout.Write(Must(libjson.GenerateStructs(*name, samples(flags.Args(), libjson.KeepNumberLiterals())...)))
+}
+
+// samples reads all values of the files, or of stdin if no files are given,
+// parsed with opts
+func samples(files []string, opts ...libjson.Option) []*libjson.JSON {
+	var docs []*libjson.JSON
+	read := func(r io.Reader) {
+		s := libjson.NewSeqReader(r, opts...)
+		for {
+			j, err := s.Next()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			j = Must(j, err)
+			docs = append(docs, &j)
+		}
+	}
+	if len(files) == 0 {
+		read(os.Stdin)
+	}
+	for _, name := range files {
+		f := Must(os.Open(name))
+		read(f)
+		f.Close()
+	}
+	return docs
+}
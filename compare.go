@@ -0,0 +1,204 @@
+package libjson
+
+import (
+	"cmp"
+	"encoding/binary"
+	"hash"
+	"hash/fnv"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+)
+
+// CompareNumbersByLiteral makes Equal compare numbers by their literal
+// instead of their value, thus 1 and 1.0 differ. The literals are only known
+// for documents parsed with KeepNumberLiterals, numbers without one are
+// compared by their float64 representation, in which only 0 and -0 differ.
+func CompareNumbersByLiteral() Option {
+	return func(c *config) {
+		c.literalNumbers = true
+	}
+}
+
+// Equal reports whether a and b hold the same json value. The order of
+// object members is ignored and numbers are compared by value, unless
+// configured via CompareNumbersByLiteral. NaN is equal to NaN.
+func Equal(a, b *JSON, opts ...Option) bool {
+	c := newConfig(opts)
+	if !c.literalNumbers {
+		return equal(a.obj, b.obj, nil, nil, false)
+	}
+	return equal(a.obj, b.obj, a.lits, b.lits, true)
+}
+
+// equal compares a and b, an and bn hold the literals of their numbers if
+// compared by literal
+func equal(a, b any, an, bn *posNode, literal bool) bool {
+	switch a := a.(type) {
+	case nil:
+		return b == nil
+	case bool:
+		b, ok := b.(bool)
+		return ok && a == b
+	case float64:
+		b, ok := b.(float64)
+		if !ok {
+			return false
+		} else if !literal {
+			return cmp.Compare(a, b) == 0
+		} else if al, bl := an.literal(), bn.literal(); al != "" && bl != "" {
+			return al == bl
+		}
+		return math.Float64bits(a) == math.Float64bits(b) || math.IsNaN(a) && math.IsNaN(b)
+	case string:
+		b, ok := b.(string)
+		return ok && a == b
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i], an.child(i), bn.child(i), literal) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, v := range a {
+			w, ok := b[k]
+			if !ok || !equal(v, w, an.child(k), bn.child(k), literal) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Compare orders a and b, returning -1 if a is less than b, 0 if they are
+// equal as reported by Equal and +1 if a is greater than b. Values of
+// different types are ordered null < boolean < number < string < array <
+// object, false < true, NaN is less than all other numbers, strings are
+// compared bytewise, arrays element by element and objects by their members
+// in ascending order of their keys, first by key and then by value.
+func Compare(a, b *JSON) int {
+	return compare(a.obj, b.obj)
+}
+
+// rank orders the types of values for compare
+func rank(v any) int {
+	switch v.(type) {
+	case nil:
+		return 0
+	case bool:
+		return 1
+	case float64:
+		return 2
+	case string:
+		return 3
+	case []any:
+		return 4
+	case map[string]any:
+		return 5
+	}
+	return 6
+}
+
+func compare(a, b any) int {
+	if r := cmp.Compare(rank(a), rank(b)); r != 0 {
+		return r
+	}
+	switch a := a.(type) {
+	case bool:
+		if b := b.(bool); a != b {
+			if a {
+				return 1
+			}
+			return -1
+		}
+	case float64:
+		return cmp.Compare(a, b.(float64))
+	case string:
+		return strings.Compare(a, b.(string))
+	case []any:
+		b := b.([]any)
+		for i := range min(len(a), len(b)) {
+			if r := compare(a[i], b[i]); r != 0 {
+				return r
+			}
+		}
+		return cmp.Compare(len(a), len(b))
+	case map[string]any:
+		b := b.(map[string]any)
+		ak, bk := slices.Sorted(maps.Keys(a)), slices.Sorted(maps.Keys(b))
+		for i := range min(len(ak), len(bk)) {
+			if r := strings.Compare(ak[i], bk[i]); r != 0 {
+				return r
+			}
+			if r := compare(a[ak[i]], b[bk[i]]); r != 0 {
+				return r
+			}
+		}
+		return cmp.Compare(len(ak), len(bk))
+	}
+	return 0
+}
+
+// Hash returns a hash of the value of j, equal values as reported by Equal
+// have the same hash, independent of the order of object members. The hash
+// is stable across processes and versions of Go, thus usable as a cache key,
+// but not suitable for cryptographic purposes.
+func Hash(j *JSON) uint64 {
+	h := fnv.New64a()
+	hashValue(h, j.obj, make([]byte, 0, 9))
+	return h.Sum64()
+}
+
+// hashValue writes a type prefixed, length delimited encoding of v to h,
+// buf is scratch space
+func hashValue(h hash.Hash64, v any, buf []byte) {
+	buf = buf[:0]
+	switch v := v.(type) {
+	case nil:
+		buf = append(buf, 'n')
+	case bool:
+		buf = append(buf, 'f')
+		if v {
+			buf[0] = 't'
+		}
+	case float64:
+		switch {
+		case v == 0:
+			// -0 equals 0
+			v = 0
+		case math.IsNaN(v):
+			v = math.NaN()
+		}
+		buf = binary.LittleEndian.AppendUint64(append(buf, 'd'), math.Float64bits(v))
+	case string:
+		buf = binary.LittleEndian.AppendUint64(append(buf, 's'), uint64(len(v)))
+		h.Write(buf)
+		h.Write([]byte(v))
+		return
+	case []any:
+		h.Write(binary.LittleEndian.AppendUint64(append(buf, 'a'), uint64(len(v))))
+		for _, e := range v {
+			hashValue(h, e, buf)
+		}
+		return
+	case map[string]any:
+		h.Write(binary.LittleEndian.AppendUint64(append(buf, 'o'), uint64(len(v))))
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			hashValue(h, k, buf)
+			hashValue(h, v[k], buf)
+		}
+		return
+	}
+	h.Write(buf)
+}
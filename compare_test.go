@@ -0,0 +1,136 @@
+package libjson
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mustNew(t *testing.T, in string) *JSON {
+	j, err := New([]byte(in), AllowNonFinite())
+	assert.NoError(t, err)
+	return &j
+}
+
+func TestEqual(t *testing.T) {
+	input := []struct {
+		a, b  string
+		equal bool
+	}{
+		{`null`, `null`, true},
+		{`null`, `false`, false},
+		{`true`, `true`, true},
+		{`1`, `1.0`, true},
+		{`1`, `1e0`, true},
+		{`100`, `1e2`, true},
+		{`0`, `-0`, true},
+		{`NaN`, `NaN`, true},
+		{`1`, `"1"`, false},
+		{`"a"`, `"a"`, true},
+		{`[1, 2]`, `[1, 2]`, true},
+		{`[1, 2]`, `[2, 1]`, false},
+		{`[1]`, `[1, 1]`, false},
+		{`{"a": 1, "b": [true]}`, `{"b": [true], "a": 1}`, true},
+		{`{"a": 1}`, `{"a": 1, "b": 1}`, false},
+		{`{"a": null}`, `{"b": null}`, false},
+		{`{"a": {"b": 1}}`, `{"a": {"b": 2}}`, false},
+		{`[]`, `{}`, false},
+	}
+	for _, i := range input {
+		t.Run(i.a+" "+i.b, func(t *testing.T) {
+			a, b := mustNew(t, i.a), mustNew(t, i.b)
+			assert.Equal(t, i.equal, Equal(a, b))
+			assert.Equal(t, i.equal, Equal(b, a))
+			assert.Equal(t, i.equal, Compare(a, b) == 0)
+			if i.equal {
+				assert.Equal(t, Hash(a), Hash(b))
+			} else {
+				assert.NotEqual(t, Hash(a), Hash(b))
+			}
+		})
+	}
+}
+
+func TestEqualLiteral(t *testing.T) {
+	literals := func(in string) *JSON {
+		j, err := New([]byte(in), AllowNonFinite(), KeepNumberLiterals())
+		assert.NoError(t, err)
+		return &j
+	}
+	input := []struct {
+		a, b  string
+		equal bool
+	}{
+		{`[1]`, `[1]`, true},
+		{`[1.0]`, `[1]`, false},
+		{`{"a": 1e2}`, `{"a": 100}`, false},
+		{`{"a": 1e2}`, `{"a": 1E2}`, false},
+		{`{"a": [0, {"b": 1.50}]}`, `{"a": [0, {"b": 1.50}]}`, true},
+		{`[0]`, `[-0]`, false},
+		{`{"a": NaN}`, `{"a": NaN}`, true},
+	}
+	for _, i := range input {
+		t.Run(i.a+" "+i.b, func(t *testing.T) {
+			a, b := literals(i.a), literals(i.b)
+			assert.Equal(t, i.equal, Equal(a, b, CompareNumbersByLiteral()))
+			assert.Equal(t, i.equal, Equal(b, a, CompareNumbersByLiteral()))
+			assert.True(t, Equal(a, b), "by value")
+		})
+	}
+
+	// without literals, only the representation of the values is compared
+	assert.False(t, Equal(mustNew(t, `[0]`), mustNew(t, `[-0]`), CompareNumbersByLiteral()))
+	assert.True(t, Equal(mustNew(t, `[1.0]`), mustNew(t, `[1]`), CompareNumbersByLiteral()))
+	assert.True(t, Equal(mustNew(t, `[1.0]`), literals(`[1]`), CompareNumbersByLiteral()))
+}
+
+func TestCompare(t *testing.T) {
+	sorted := []string{
+		`null`,
+		`false`,
+		`true`,
+		`NaN`,
+		`-Infinity`,
+		`-1`,
+		`0`,
+		`1.5`,
+		`""`,
+		`"a"`,
+		`"ab"`,
+		`"b"`,
+		`[]`,
+		`[1]`,
+		`[1, 1]`,
+		`[2]`,
+		`{}`,
+		`{"a": 1}`,
+		`{"a": 1, "b": 1}`,
+		`{"a": 2}`,
+		`{"b": 0}`,
+	}
+	docs := make([]*JSON, len(sorted))
+	for i, s := range sorted {
+		docs[i] = mustNew(t, s)
+	}
+	for i := range docs {
+		for j := range docs {
+			assert.Equal(t, min(max(i-j, -1), 1), Compare(docs[i], docs[j]), "%s <=> %s", sorted[i], sorted[j])
+		}
+	}
+	shuffled := slices.Clone(docs)
+	slices.Reverse(shuffled)
+	slices.SortFunc(shuffled, Compare)
+	assert.Equal(t, docs, shuffled)
+}
+
+func TestHash(t *testing.T) {
+	// stable across runs and processes
+	assert.Equal(t, uint64(0xaf63e34c8601f871), Hash(mustNew(t, `null`)))
+	// length prefixes keep the boundaries of strings, arrays and objects
+	assert.NotEqual(t, Hash(mustNew(t, `["ab", "c"]`)), Hash(mustNew(t, `["a", "bc"]`)))
+	assert.NotEqual(t, Hash(mustNew(t, `[[1], 2]`)), Hash(mustNew(t, `[[1, 2]]`)))
+	assert.NotEqual(t, Hash(mustNew(t, `{"a": "b"}`)), Hash(mustNew(t, `["a", "b"]`)))
+	assert.Equal(t, Hash(mustNew(t, `[0]`)), Hash(&JSON{obj: []any{math.Copysign(0, -1)}}))
+}
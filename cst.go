@@ -0,0 +1,509 @@
+package libjson
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// CST is a lossless concrete syntax tree of a document, holding the bytes of
+// every token as well as the whitespace and comments between them, thus
+// Bytes reproduces the input byte by byte. Set, Insert and Delete edit the
+// tree, only the edited values are serialized anew, every other region of
+// the document stays byte-identical. This is intended for editing
+// configuration files, usually combined with UseDialect(JSONC).
+type CST struct {
+	root *cstNode
+	// whitespace and comments following the root value
+	after []byte
+	config
+}
+
+// cstNode is a value of a CST
+type cstNode struct {
+	// whitespace and comments preceding the value
+	before []byte
+	kind   Kind
+	// the token of a scalar
+	raw []byte
+	// members of an object or elements of an array
+	members []*cstMember
+	// whitespace and comments between the opening bracket or the last comma
+	// and the closing bracket
+	end []byte
+}
+
+// cstMember is a member of an object or an element of an array, for arrays
+// the whitespace and comments preceding an element are stored in its value
+type cstMember struct {
+	// whitespace and comments preceding the key
+	before []byte
+	// the token of the key and its content, only used for objects
+	key  []byte
+	name string
+	// whitespace and comments between the key and the colon, the ones
+	// between the colon and the value are stored in the value
+	colon []byte
+	value *cstNode
+	// whitespace and comments between the value and the comma or the
+	// closing bracket
+	after []byte
+	comma bool
+}
+
+// ParseCST parses data into a CST, opts are applied as for New. The CST
+// aliases data, thus data must not be modified as long as the CST is in use.
+func ParseCST(data []byte, opts ...Option) (*CST, error) {
+	c := &CST{config: newConfig(opts)}
+	root, after, err := c.parse(data)
+	if err != nil {
+		return nil, err
+	}
+	c.root, c.after = root, after
+	return c, nil
+}
+
+// parse returns the root value of data and the whitespace and comments
+// following it
+func (c *CST) parse(data []byte) (*cstNode, []byte, error) {
+	p := cstParser{parser: parser{config: c.config}}
+	p.l = lexer{data: data, dialect: c.dialect, nonFinite: c.allowNonFinite}
+	if err := p.advance(); err != nil {
+		return nil, nil, p.syntaxError(err)
+	}
+	n, err := p.value()
+	if err != nil {
+		return nil, nil, p.syntaxError(err)
+	}
+	if p.cur_tok.Type != t_eof {
+		return nil, nil, p.syntaxError(fmt.Errorf("Unexpected non-whitespace character(s) (%s) after JSON data", tokennames[p.cur_tok.Type]))
+	}
+	return n, p.trivia, nil
+}
+
+// Bytes returns the document including all edits
+func (c *CST) Bytes() []byte {
+	return append(c.root.append(nil), c.after...)
+}
+
+// Set replaces the value at path with value, keeping the whitespace and
+// comments surrounding it. If the object member at path does not exist or
+// path refers to the element following the last one of an array, value is
+// inserted, see Insert.
+func (c *CST) Set(path string, value []byte) error {
+	v, err := c.value(value)
+	if err != nil {
+		return err
+	}
+	parent, i, name, err := c.lookup(path)
+	if err != nil {
+		return err
+	}
+	if parent == nil {
+		v.before = c.root.before
+		c.root = v
+	} else if i < 0 || i == len(parent.members) {
+		parent.insert(i, name, v)
+	} else {
+		m := parent.members[i]
+		v.before = m.value.before
+		m.value = v
+	}
+	return nil
+}
+
+// Insert inserts value as the object member or the array element at path,
+// shifting the elements following it. The whitespace surrounding the new
+// member is modelled after its siblings.
+func (c *CST) Insert(path string, value []byte) error {
+	v, err := c.value(value)
+	if err != nil {
+		return err
+	}
+	parent, i, name, err := c.lookup(path)
+	if err != nil {
+		return err
+	}
+	if parent == nil {
+		return errors.New("Can not insert at the top level, use Set to replace it")
+	}
+	if parent.kind == KindObject && i >= 0 {
+		return fmt.Errorf("Member %q already exists, use Set to replace it", name)
+	}
+	parent.insert(i, name, v)
+	return nil
+}
+
+// Delete removes the object member or array element at path, including the
+// comments preceding it
+func (c *CST) Delete(path string) error {
+	parent, i, _, err := c.lookup(path)
+	if err != nil {
+		return err
+	}
+	if parent == nil {
+		return errors.New("Can not delete the top level value")
+	}
+	if i < 0 || i == len(parent.members) {
+		return fmt.Errorf("No value at %q", path)
+	}
+	parent.remove(i)
+	return nil
+}
+
+// value parses the value to be inserted into c, dropping its surrounding
+// whitespace and comments
+func (c *CST) value(data []byte) (*cstNode, error) {
+	v, _, err := c.parse(data)
+	if err != nil {
+		return nil, err
+	}
+	v.before = nil
+	return v, nil
+}
+
+// lookup returns the container holding the value at path and the index of
+// said value in the container, which is -1 for a missing object member and
+// may equal the amount of elements for arrays. The top level value has no
+// container.
+func (c *CST) lookup(path string) (*cstNode, int, string, error) {
+	keys, err := splitPath(path)
+	if err != nil {
+		return nil, 0, "", fmt.Errorf("%w: %q", errors.ErrUnsupported, path)
+	}
+	n := c.root
+	for j, k := range keys {
+		i, name, err := n.index(k)
+		if err != nil {
+			return nil, 0, "", err
+		}
+		if j == len(keys)-1 {
+			return n, i, name, nil
+		}
+		if i < 0 || i == len(n.members) {
+			return nil, 0, "", fmt.Errorf("No value at %q", path)
+		}
+		n = n.members[i].value
+	}
+	return nil, 0, "", nil
+}
+
+// index returns the index of the member or element of n referred to by k,
+// see CST.lookup
+func (n *cstNode) index(k any) (int, string, error) {
+	switch n.kind {
+	case KindObject:
+		name, ok := k.(string)
+		if !ok {
+			name = strconv.Itoa(k.(int))
+		}
+		// the last duplicate member wins, as for New
+		for i := len(n.members) - 1; i >= 0; i-- {
+			if n.members[i].name == name {
+				return i, name, nil
+			}
+		}
+		return -1, name, nil
+	case KindArray:
+		i, ok := k.(int)
+		if !ok || i > len(n.members) {
+			return 0, "", fmt.Errorf("Can not use %T::%v to index into array of length %d", k, k, len(n.members))
+		}
+		return i, "", nil
+	}
+	return 0, "", fmt.Errorf("Can not index into %s", n.kind)
+}
+
+// insert adds v named name as the member at index i of n, -1 appends. The
+// whitespace of the new member is copied from the last member of n, if v is
+// appended, the trailing comments of the former last member stay in front of
+// the added comma.
+func (n *cstNode) insert(i int, name string, v *cstNode) {
+	if i < 0 {
+		i = len(n.members)
+	}
+	m := &cstMember{value: v}
+	if n.kind == KindObject {
+		m.key = AppendString(nil, name)
+		m.name = name
+		v.before = []byte(" ")
+	}
+	if len(n.members) == 0 {
+		// indent the single member of a multi-line container
+		if bytes.IndexByte(n.end, '\n') >= 0 {
+			m.setLead(slices.Concat(layout(n.end), []byte("  ")))
+		}
+		n.members = append(n.members, m)
+		return
+	}
+
+	ref := n.members[len(n.members)-1]
+	lead := layout(ref.lead())
+	if n.kind == KindObject {
+		if isSpace(ref.colon) {
+			m.colon = ref.colon
+		}
+		if isSpace(ref.value.before) {
+			v.before = ref.value.before
+		}
+	}
+	if i == len(n.members) {
+		m.comma = ref.comma
+		if !ref.comma {
+			ref.comma = true
+			t := ref.after
+			ref.after = nil
+			if isSpace(t) {
+				m.after = t
+			} else {
+				nl := bytes.LastIndexByte(t, '\n')
+				if nl < 0 {
+					nl = len(t)
+				}
+				lead = slices.Concat(t[:nl], lead)
+				m.after = t[nl:]
+			}
+		}
+	} else {
+		m.comma = true
+		// the new first member takes the place of the former one
+		if first := n.members[0]; i == 0 && isSpace(first.lead()) {
+			lead = first.lead()
+			first.setLead(layout(ref.lead()))
+		}
+	}
+	m.setLead(lead)
+	n.members = slices.Insert(n.members, i, m)
+}
+
+// remove deletes the member at index i of n, including its comments, these
+// are the comments preceding it, except for the ones following the preceding
+// comma on the same line, and the comments following it on the same line
+func (n *cstNode) remove(i int) {
+	m := n.members[i]
+	n.members = slices.Delete(n.members, i, i+1)
+	head := sameLine(m.lead())
+	switch {
+	case len(n.members) == 0:
+		if isSpace(m.after) {
+			n.end = slices.Concat(head, m.after, n.end)
+		} else {
+			n.end = slices.Concat(head, n.end)
+		}
+	case i == len(n.members):
+		if prev := n.members[i-1]; m.comma {
+			n.end = slices.Concat(head, n.end[len(sameLine(n.end)):])
+		} else {
+			prev.comma = false
+			prev.after = slices.Concat(prev.after, head, trailing(m.after))
+		}
+	default:
+		next := n.members[i]
+		if i == 0 && isSpace(m.lead()) && isSpace(next.lead()) {
+			next.setLead(m.lead())
+		} else {
+			lead := next.lead()
+			next.setLead(slices.Concat(head, lead[len(sameLine(lead)):]))
+		}
+	}
+}
+
+// lead returns the whitespace and comments preceding m
+func (m *cstMember) lead() []byte {
+	if m.key != nil {
+		return m.before
+	}
+	return m.value.before
+}
+
+func (m *cstMember) setLead(b []byte) {
+	if m.key != nil {
+		m.before = b
+	} else {
+		m.value.before = b
+	}
+}
+
+func (n *cstNode) append(buf []byte) []byte {
+	buf = append(buf, n.before...)
+	var open, closing byte
+	switch n.kind {
+	case KindObject:
+		open, closing = '{', '}'
+	case KindArray:
+		open, closing = '[', ']'
+	default:
+		return append(buf, n.raw...)
+	}
+	buf = append(buf, open)
+	for _, m := range n.members {
+		if m.key != nil {
+			buf = append(buf, m.before...)
+			buf = append(buf, m.key...)
+			buf = append(buf, m.colon...)
+			buf = append(buf, ':')
+		}
+		buf = m.value.append(buf)
+		buf = append(buf, m.after...)
+		if m.comma {
+			buf = append(buf, ',')
+		}
+	}
+	buf = append(buf, n.end...)
+	return append(buf, closing)
+}
+
+// isSpace reports whether t holds no comments
+func isSpace(t []byte) bool {
+	return len(bytes.TrimLeft(t, " \t\r\n")) == 0
+}
+
+// layout returns the whitespace of t without comments, for multiple lines
+// the line break and the indentation of the last line
+func layout(t []byte) []byte {
+	nl := bytes.LastIndexByte(t, '\n')
+	if nl < 0 {
+		if isSpace(t) {
+			return t
+		}
+		return []byte(" ")
+	}
+	rest := t[nl+1:]
+	indent := rest[:len(rest)-len(bytes.TrimLeft(rest, " \t"))]
+	if nl > 0 && t[nl-1] == '\r' {
+		return slices.Concat([]byte("\r\n"), indent)
+	}
+	return slices.Concat([]byte("\n"), indent)
+}
+
+// sameLine returns the comments of t preceding its first line break, these
+// belong to the value preceding t
+func sameLine(t []byte) []byte {
+	nl := bytes.IndexByte(t, '\n')
+	if nl < 0 || isSpace(t[:nl]) {
+		return nil
+	}
+	if nl > 0 && t[nl-1] == '\r' {
+		nl--
+	}
+	return t[:nl]
+}
+
+// trailing drops the comments of t, keeping the line break and indentation
+// of the closing bracket
+func trailing(t []byte) []byte {
+	if isSpace(t) {
+		return t
+	}
+	if nl := bytes.LastIndexByte(t, '\n'); nl >= 0 {
+		return t[nl:]
+	}
+	return nil
+}
+
+// cstParser records the whitespace and comments preceding each token
+type cstParser struct {
+	parser
+	// end of the previous token
+	prev int
+	// whitespace and comments preceding the current token
+	trivia []byte
+}
+
+func (p *cstParser) advance() error {
+	if err := p.parser.advance(); err != nil {
+		return err
+	}
+	start, end := p.l.start, p.l.pos
+	if p.cur_tok.Type == t_eof {
+		start, end = len(p.l.data), len(p.l.data)
+	}
+	p.trivia = p.l.data[p.prev:start]
+	p.prev = end
+	return nil
+}
+
+// span returns the bytes of the current token
+func (p *cstParser) span() []byte {
+	return p.l.data[p.l.start:p.prev]
+}
+
+func (p *cstParser) value() (*cstNode, error) {
+	n := &cstNode{before: p.trivia}
+	switch p.cur_tok.Type {
+	case t_left_curly:
+		n.kind = KindObject
+		return n, p.members(n, t_right_curly)
+	case t_left_braket:
+		n.kind = KindArray
+		return n, p.members(n, t_right_braket)
+	case t_string:
+		n.kind = KindString
+	case t_number:
+		if _, err := p.number(); err != nil {
+			return nil, err
+		}
+		n.kind = KindNumber
+	case t_true, t_false:
+		n.kind = KindBool
+	case t_null:
+		n.kind = KindNull
+	default:
+		return nil, fmt.Errorf("Unexpected %q at this position, expected any of: string, number, true, false or null", tokennames[p.cur_tok.Type])
+	}
+	n.raw = p.span()
+	return n, p.advance()
+}
+
+// members parses the members of an object or the elements of an array up to
+// and including the closing bracket
+func (p *cstParser) members(n *cstNode, closing t_json) error {
+	if err := p.advance(); err != nil {
+		return err
+	}
+	for p.cur_tok.Type != closing {
+		m := &cstMember{}
+		if n.kind == KindObject {
+			if p.cur_tok.Type != t_string && !p.identifier() {
+				return fmt.Errorf("Unexpected %q at this position, expected %q", tokennames[p.cur_tok.Type], tokennames[t_string])
+			}
+			m.before, m.key, m.name = p.trivia, p.span(), p.str()
+			if err := p.advance(); err != nil {
+				return err
+			}
+			if p.cur_tok.Type != t_colon {
+				return fmt.Errorf("Unexpected %q at this position, expected %q", tokennames[p.cur_tok.Type], tokennames[t_colon])
+			}
+			m.colon = p.trivia
+			if err := p.advance(); err != nil {
+				return err
+			}
+		}
+		v, err := p.value()
+		if err != nil {
+			return err
+		}
+		m.value, m.after = v, p.trivia
+		n.members = append(n.members, m)
+
+		if p.cur_tok.Type == t_comma {
+			m.comma = true
+			if err := p.advance(); err != nil {
+				return err
+			}
+			if p.cur_tok.Type == closing && p.l.dialect == Strict {
+				return fmt.Errorf("Unexpected %q after %q, trailing commas require JSONC or JSON5", tokennames[closing], tokennames[t_comma])
+			}
+		} else if p.cur_tok.Type != closing {
+			return fmt.Errorf("Unexpected %q at this position, expected %q", tokennames[p.cur_tok.Type], tokennames[t_comma])
+		}
+	}
+	// otherwise the whitespace preceding the closing bracket follows the
+	// last value
+	if len(n.members) == 0 || n.members[len(n.members)-1].comma {
+		n.end = p.trivia
+	}
+	return p.advance()
+}
@@ -0,0 +1,226 @@
+package libjson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const cstConfig = `// editor settings
+{
+  "fontSize": 14, // pt
+  /* the theme */
+  "colorTheme": "Monokai",
+  "exclude": {
+    "**/.git": true
+  },
+  "recent": [1, 2, 3]
+}
+`
+
+func TestCSTRoundtrip(t *testing.T) {
+	input := map[Dialect][]string{
+		Strict: {
+			`1`,
+			"  \n\"str\"\t\n",
+			`{}`,
+			`[ ]`,
+			`{ "a" : [ 1 , { "b":null } ] , "c":true }`,
+			"{\r\n\t\"a\": 1\r\n}\r\n",
+		},
+		JSONC: {
+			cstConfig,
+			`/* only */ [1, 2, /* three */ 3, ] // trailing`,
+			"{\"a\": 1, // one\n}",
+		},
+		JSON5: {
+			`{unquoted: 'single', hex: 0xFF, trailing: .5, }`,
+			`[Infinity, +1, 'multi\
+line']`,
+		},
+	}
+	for d, in := range input {
+		for _, i := range in {
+			t.Run(i, func(t *testing.T) {
+				c, err := ParseCST([]byte(i), UseDialect(d))
+				assert.NoError(t, err)
+				assert.Equal(t, i, string(c.Bytes()))
+			})
+		}
+	}
+}
+
+func TestCSTEdit(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		edit func(c *CST) error
+		want string
+	}{
+		{
+			name: "set",
+			in:   cstConfig,
+			edit: func(c *CST) error { return c.Set(".fontSize", []byte("16")) },
+		},
+		{
+			name: "set escaped key",
+			in:   `{"a\"b": 1, "c": 2}`,
+			edit: func(c *CST) error { return c.Set(`.a"b`, []byte("3")) },
+			want: `{"a\"b": 3, "c": 2}`,
+		},
+		{
+			name: "set container",
+			in:   `{"a": /* keep */ [1, 2] /* me */, "b": 1}`,
+			edit: func(c *CST) error { return c.Set(".a", []byte(`  {"x": [true]}  `)) },
+			want: `{"a": /* keep */ {"x": [true]} /* me */, "b": 1}`,
+		},
+		{
+			name: "set top level",
+			in:   " // c\n[1]\n",
+			edit: func(c *CST) error { return c.Set(".", []byte(`{}`)) },
+			want: " // c\n{}\n",
+		},
+		{
+			name: "set inserts",
+			in:   `{"a": 1}`,
+			edit: func(c *CST) error { return c.Set(".b", []byte(`2`)) },
+			want: `{"a": 1,"b": 2}`,
+		},
+		{
+			name: "insert after trailing comment",
+			in:   "{\n  \"a\": 1 // one\n}",
+			edit: func(c *CST) error { return c.Insert(".b", []byte(`"two"`)) },
+			want: "{\n  \"a\": 1, // one\n  \"b\": \"two\"\n}",
+		},
+		{
+			name: "insert nested",
+			in:   cstConfig,
+			edit: func(c *CST) error { return c.Insert(".exclude.node_modules", []byte(`true`)) },
+		},
+		{
+			name: "insert with trailing comma",
+			in:   "[\n\t1,\n\t2,\n]",
+			edit: func(c *CST) error { return c.Insert(".2", []byte(`3`)) },
+			want: "[\n\t1,\n\t2,\n\t3,\n]",
+		},
+		{
+			name: "insert first",
+			in:   `[1, 2, 3]`,
+			edit: func(c *CST) error { return c.Insert(".0", []byte(`0`)) },
+			want: `[0, 1, 2, 3]`,
+		},
+		{
+			name: "insert middle",
+			in:   "[\n  1,\n  // three\n  3\n]",
+			edit: func(c *CST) error { return c.Insert(".1", []byte(`2`)) },
+			want: "[\n  1,\n  2,\n  // three\n  3\n]",
+		},
+		{
+			name: "insert into empty",
+			in:   "{\n}",
+			edit: func(c *CST) error { return c.Insert(".a", []byte(`[]`)) },
+			want: "{\n  \"a\": []\n}",
+		},
+		{
+			name: "insert into empty inline",
+			in:   `[]`,
+			edit: func(c *CST) error { return c.Insert(".0", []byte(`"x"`)) },
+			want: `["x"]`,
+		},
+		{
+			name: "delete last",
+			in:   "{\n  \"a\": 1,\n  \"b\": 2 // two\n}",
+			edit: func(c *CST) error { return c.Delete(".b") },
+			want: "{\n  \"a\": 1\n}",
+		},
+		{
+			name: "delete middle with comment",
+			in:   cstConfig,
+			edit: func(c *CST) error { return c.Delete(".colorTheme") },
+		},
+		{
+			name: "delete first",
+			in:   `[1, 2, 3]`,
+			edit: func(c *CST) error { return c.Delete(".0") },
+			want: `[2, 3]`,
+		},
+		{
+			name: "delete only",
+			in:   "{\n  \"a\": 1\n}",
+			edit: func(c *CST) error { return c.Delete(".a") },
+			want: "{\n}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCST([]byte(tt.in), UseDialect(JSONC))
+			assert.NoError(t, err)
+			assert.NoError(t, tt.edit(c))
+			if tt.want != "" {
+				assert.Equal(t, tt.want, string(c.Bytes()))
+			}
+			_, err = New(c.Bytes(), UseDialect(JSONC))
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestCSTEditConfig(t *testing.T) {
+	c, err := ParseCST([]byte(cstConfig), UseDialect(JSONC))
+	assert.NoError(t, err)
+	assert.NoError(t, c.Set(".fontSize", []byte("16")))
+	assert.NoError(t, c.Insert(".exclude.node_modules", []byte("true")))
+	assert.NoError(t, c.Delete(".colorTheme"))
+	assert.NoError(t, c.Delete(".recent.1"))
+	assert.Equal(t, `// editor settings
+{
+  "fontSize": 16, // pt
+  "exclude": {
+    "**/.git": true,
+    "node_modules": true
+  },
+  "recent": [1, 3]
+}
+`, string(c.Bytes()))
+}
+
+func TestCSTFail(t *testing.T) {
+	for _, in := range []string{``, `[1,]`, `{"a" 1}`, `{"a": 1 "b": 2}`, `[1] 2`, `// comment`} {
+		t.Run(in, func(t *testing.T) {
+			_, err := ParseCST([]byte(in))
+			assert.Error(t, err)
+		})
+	}
+
+	c, err := ParseCST([]byte(`{"a": [1], "s": "str"}`))
+	assert.NoError(t, err)
+	assert.Error(t, c.Set(".a", []byte(`[1,`)))
+	assert.Error(t, c.Set(".s.x", []byte(`1`)))
+	assert.Error(t, c.Set(".a.5", []byte(`1`)))
+	assert.Error(t, c.Set(".x.y", []byte(`1`)))
+	assert.Error(t, c.Insert(".a.x", []byte(`1`)))
+	assert.Error(t, c.Insert(".a", []byte(`1`)))
+	assert.Error(t, c.Insert(".", []byte(`1`)))
+	assert.Error(t, c.Delete(".b"))
+	assert.Error(t, c.Delete(".a.1"))
+	assert.Error(t, c.Delete("."))
+	assert.Error(t, c.Delete(""))
+	assert.Equal(t, `{"a": [1], "s": "str"}`, string(c.Bytes()))
+}
+
+func TestCSTDeleteKeepsTrailingComments(t *testing.T) {
+	tests := map[string][2]string{
+		".b": {"{\n  \"a\": 1, // one\n  \"b\": 2\n}", "{\n  \"a\": 1 // one\n}"},
+		".1": {"[\n  1, // one\n  2, // two\n  3\n]", "[\n  1, // one\n  3\n]"},
+		".0": {"[ // items\n  1,\n  2\n]", "[ // items\n  2\n]"},
+		".2": {"[1, 2, /* two */\n  3,\n]", "[1, 2, /* two */\n]"},
+	}
+	for path, tt := range tests {
+		t.Run(tt[0], func(t *testing.T) {
+			c, err := ParseCST([]byte(tt[0]), UseDialect(JSONC))
+			assert.NoError(t, err)
+			assert.NoError(t, c.Delete(path))
+			assert.Equal(t, tt[1], string(c.Bytes()))
+		})
+	}
+}
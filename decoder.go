@@ -0,0 +1,526 @@
+package libjson
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"unsafe"
+)
+
+// TokenKind is the type of a Token returned by (*Decoder).Token
+type TokenKind uint8
+
+const (
+	TokenObjectStart TokenKind = iota // {
+	TokenObjectEnd                    // }
+	TokenArrayStart                   // [
+	TokenArrayEnd                     // ]
+	TokenKey                          // object key, Value is a string
+	TokenString                       // Value is a string
+	TokenNumber                       // Value is a float64
+	TokenBool                         // Value is a bool
+	TokenNull                         // Value is nil
+)
+
+var tokenkindnames = map[TokenKind]string{
+	TokenObjectStart: "{",
+	TokenObjectEnd:   "}",
+	TokenArrayStart:  "[",
+	TokenArrayEnd:    "]",
+	TokenKey:         "key",
+	TokenString:      "string",
+	TokenNumber:      "number",
+	TokenBool:        "bool",
+	TokenNull:        "null",
+}
+
+func (k TokenKind) String() string {
+	return tokenkindnames[k]
+}
+
+// Token is a single json token, commas and colons are validated by the
+// Decoder but never returned
+type Token struct {
+	Kind  TokenKind
+	Value any
+	// byte offsets of the token in the input, strings and keys include their
+	// quotes
+	Start int64
+	End   int64
+}
+
+// frame is a container the Decoder is currently in
+type frame struct {
+	// either t_left_curly or t_left_braket
+	typ t_json
+	// the container already holds an element, thus the next one has to be
+	// preceded by a comma
+	comma bool
+}
+
+// Decoder reads a stream of json values token by token, while checking the
+// structure of the input, thus arrays with millions of elements can be
+// processed one element at a time. The stream may consist of multiple top
+// level values.
+type Decoder struct {
+	p       parser
+	stack   []frame
+	started bool
+	// the key of the current member was returned, its value comes next
+	value bool
+	// the comma preceding the next element was already consumed, see Peek
+	separated bool
+	// copy of the last key returned by ReadKeyBytes when streaming
+	buf []byte
+	err error
+}
+
+// NewDecoder returns a Decoder reading from r, see NewReader
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{
+		p:     parser{l: lexer{r: r, data: make([]byte, 0, bufSize)}, keys: make(map[string]string, 64)},
+		stack: make([]frame, 0, 8),
+	}
+}
+
+// NewDecoderBytes returns a Decoder reading from data, strings are copied,
+// see CopyStrings
+func NewDecoderBytes(data []byte) *Decoder {
+	return &Decoder{
+		p:     parser{l: lexer{data: data, idx: newStructuralIndex(len(data))}, config: config{copy: true}},
+		stack: make([]frame, 0, 8),
+	}
+}
+
+func (d *Decoder) fail(err error) error {
+	d.err = err
+	return err
+}
+
+func (d *Decoder) start() error {
+	if !d.started {
+		d.started = true
+		if err := d.p.advance(); err != nil {
+			return d.fail(err)
+		}
+	}
+	return d.err
+}
+
+// separator consumes the comma between two elements of the current
+// container and reports whether the next token is an object key
+func (d *Decoder) separator() (bool, error) {
+	if len(d.stack) == 0 || d.value {
+		return false, nil
+	}
+	top := &d.stack[len(d.stack)-1]
+	if top.comma && !d.separated {
+		if d.p.cur_tok.Type != t_comma {
+			return false, d.fail(fmt.Errorf("Unexpected %q at this position, expected %q", tokennames[d.p.cur_tok.Type], tokennames[t_comma]))
+		}
+		if err := d.p.advance(); err != nil {
+			return false, d.fail(err)
+		}
+	}
+	top.comma = true
+	d.separated = true
+	return top.typ == t_left_curly, nil
+}
+
+// closing reports whether the current token closes the current container
+func (d *Decoder) closing() bool {
+	if len(d.stack) == 0 || d.value || d.separated {
+		return false
+	}
+	t := d.p.cur_tok.Type
+	return (t == t_right_curly && d.stack[len(d.stack)-1].typ == t_left_curly) ||
+		(t == t_right_braket && d.stack[len(d.stack)-1].typ == t_left_braket)
+}
+
+// Token returns the next token of the input, io.EOF signals the end of the
+// input outside of any container
+func (d *Decoder) Token() (Token, error) {
+	if err := d.start(); err != nil {
+		return Token{}, err
+	}
+	if len(d.stack) == 0 && d.p.cur_tok.Type == t_eof {
+		return Token{}, io.EOF
+	}
+
+	if d.closing() {
+		kind := TokenObjectEnd
+		if d.p.cur_tok.Type == t_right_braket {
+			kind = TokenArrayEnd
+		}
+		t := d.token(kind, nil)
+		d.stack = d.stack[:len(d.stack)-1]
+		if err := d.p.advance(); err != nil {
+			return Token{}, d.fail(err)
+		}
+		return t, nil
+	}
+
+	isKey, err := d.separator()
+	if err != nil {
+		return Token{}, err
+	}
+	if isKey {
+		return d.key()
+	}
+	d.value = false
+	d.separated = false
+
+	switch d.p.cur_tok.Type {
+	case t_left_curly, t_left_braket:
+		kind := TokenObjectStart
+		if d.p.cur_tok.Type == t_left_braket {
+			kind = TokenArrayStart
+		}
+		t := d.token(kind, nil)
+		d.stack = append(d.stack, frame{typ: d.p.cur_tok.Type})
+		if err := d.p.advance(); err != nil {
+			return Token{}, d.fail(err)
+		}
+		return t, nil
+	default:
+		var kind TokenKind
+		switch d.p.cur_tok.Type {
+		case t_string:
+			kind = TokenString
+		case t_number:
+			kind = TokenNumber
+		case t_true, t_false:
+			kind = TokenBool
+		case t_null:
+			kind = TokenNull
+		}
+		t := d.token(kind, nil)
+		val, err := d.p.atom()
+		if err != nil {
+			return Token{}, d.fail(err)
+		}
+		t.Value = val
+		return t, nil
+	}
+}
+
+// key reads an object key and the following colon
+func (d *Decoder) key() (Token, error) {
+	t := d.token(TokenKey, nil)
+	k, err := d.readKey()
+	if err != nil {
+		return Token{}, err
+	}
+	t.Value = k
+	return t, nil
+}
+
+// readKey is key without creating a Token, thus the key is not boxed
+func (d *Decoder) readKey() (string, error) {
+	if d.p.cur_tok.Type != t_string {
+		return "", d.fail(fmt.Errorf("Unexpected %q at this position, expected %q", tokennames[d.p.cur_tok.Type], tokennames[t_string]))
+	}
+	k := d.p.key()
+	return k, d.colon()
+}
+
+// colon advances past the current key and the following colon
+func (d *Decoder) colon() error {
+	if err := d.p.advance(); err != nil {
+		return d.fail(err)
+	}
+	if d.p.cur_tok.Type != t_colon {
+		return d.fail(fmt.Errorf("Unexpected %q at this position, expected %q", tokennames[d.p.cur_tok.Type], tokennames[t_colon]))
+	}
+	if err := d.p.advance(); err != nil {
+		return d.fail(err)
+	}
+	d.value = true
+	d.separated = false
+	return nil
+}
+
+// token creates a Token of kind at the position of the current token
+func (d *Decoder) token(kind TokenKind, val any) Token {
+	return Token{
+		Kind:  kind,
+		Value: val,
+		Start: d.p.l.base + int64(d.p.l.start),
+		End:   d.p.l.base + int64(d.p.l.pos),
+	}
+}
+
+// More reports whether there is another element in the current array or
+// object, or another top level value in the input
+func (d *Decoder) More() bool {
+	if d.start() != nil {
+		return false
+	}
+	t := d.p.cur_tok.Type
+	return t != t_eof && !d.closing()
+}
+
+// Skip jumps over the next value, if the next token is an object key, the key
+// and its value are skipped
+func (d *Decoder) Skip() error {
+	if err := d.start(); err != nil {
+		return err
+	}
+	if d.closing() {
+		return d.fail(fmt.Errorf("Unexpected %q at this position, expected a value to skip", tokennames[d.p.cur_tok.Type]))
+	}
+	depth := 0
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t.Kind {
+		case TokenObjectStart, TokenArrayStart:
+			depth++
+		case TokenObjectEnd, TokenArrayEnd:
+			depth--
+		case TokenKey:
+			if depth == 0 {
+				continue
+			}
+		}
+		if depth <= 0 {
+			return nil
+		}
+	}
+}
+
+// Decode builds the value at the current position, which must not be an
+// object key
+func (d *Decoder) Decode() (JSON, error) {
+	if err := d.start(); err != nil {
+		return JSON{}, err
+	}
+	if len(d.stack) == 0 && d.p.cur_tok.Type == t_eof {
+		return JSON{}, io.EOF
+	}
+	if d.closing() {
+		return JSON{}, d.fail(fmt.Errorf("Unexpected %q at this position, expected a value", tokennames[d.p.cur_tok.Type]))
+	}
+	isKey, err := d.separator()
+	if err != nil {
+		return JSON{}, err
+	}
+	if isKey {
+		return JSON{}, d.fail(errors.New("Unexpected object key at this position, expected a value"))
+	}
+	d.value = false
+	d.separated = false
+	obj, err := d.p.expression()
+	if err != nil {
+		return JSON{}, d.fail(err)
+	}
+	return JSON{obj: obj}, nil
+}
+
+// Peek returns the kind of the next token without consuming it
+func (d *Decoder) Peek() (TokenKind, error) {
+	if err := d.start(); err != nil {
+		return 0, err
+	}
+	if len(d.stack) == 0 && d.p.cur_tok.Type == t_eof {
+		return 0, io.EOF
+	}
+	if d.closing() {
+		if d.p.cur_tok.Type == t_right_braket {
+			return TokenArrayEnd, nil
+		}
+		return TokenObjectEnd, nil
+	}
+	isKey, err := d.separator()
+	if err != nil {
+		return 0, err
+	}
+	if isKey {
+		return TokenKey, nil
+	}
+	switch d.p.cur_tok.Type {
+	case t_left_curly:
+		return TokenObjectStart, nil
+	case t_left_braket:
+		return TokenArrayStart, nil
+	case t_string:
+		return TokenString, nil
+	case t_number:
+		return TokenNumber, nil
+	case t_true, t_false:
+		return TokenBool, nil
+	case t_null:
+		return TokenNull, nil
+	default:
+		return 0, d.fail(fmt.Errorf("Unexpected %q at this position, expected any of: string, number, true, false or null", tokennames[d.p.cur_tok.Type]))
+	}
+}
+
+// Expect returns the next token, failing if it is not of the given kind
+func (d *Decoder) Expect(kind TokenKind) (Token, error) {
+	k, err := d.Peek()
+	if err != nil {
+		return Token{}, err
+	}
+	if k != kind {
+		return Token{}, fmt.Errorf("Unexpected %q at this position, expected %q", k, kind)
+	}
+	return d.Token()
+}
+
+// End reports an error if anything but whitespace follows the values read
+// so far
+func (d *Decoder) End() error {
+	if err := d.start(); err != nil {
+		return err
+	}
+	if len(d.stack) != 0 || d.p.cur_tok.Type != t_eof {
+		return d.fail(fmt.Errorf("Unexpected non-whitespace character(s) (%s) after JSON data", tokennames[d.p.cur_tok.Type]))
+	}
+	return nil
+}
+
+// Null consumes the next value if it is null and reports whether it did so
+func (d *Decoder) Null() (bool, error) {
+	k, err := d.Peek()
+	if err != nil || k != TokenNull {
+		return false, err
+	}
+	_, err = d.Token()
+	return err == nil, err
+}
+
+// scalar returns the raw bytes of the next value, which has to be of kind,
+// the value is consumed via d.consume
+func (d *Decoder) scalar(kind TokenKind) (string, error) {
+	k, err := d.Peek()
+	if err != nil {
+		return "", err
+	}
+	if k != kind {
+		return "", fmt.Errorf("Unexpected %q at this position, expected %q", k, kind)
+	}
+	in := d.p.l.data[d.p.cur_tok.Start:d.p.cur_tok.End]
+	return *(*string)(unsafe.Pointer(&in)), nil
+}
+
+// consume advances past the value returned by d.scalar, err is the error of
+// converting it
+func (d *Decoder) consume(err error) error {
+	d.value = false
+	d.separated = false
+	if aerr := d.p.advance(); aerr != nil {
+		return d.fail(aerr)
+	}
+	return err
+}
+
+// ReadKey returns the next object key, see Token
+func (d *Decoder) ReadKey() (string, error) {
+	k, err := d.Peek()
+	if err != nil {
+		return "", err
+	}
+	if k != TokenKey {
+		return "", fmt.Errorf("Unexpected %q at this position, expected %q", k, TokenKey)
+	}
+	return d.readKey()
+}
+
+// ReadKeyBytes is ReadKey without copying the key, the result aliases the
+// buffer of d and is only valid until the next call to d
+func (d *Decoder) ReadKeyBytes() ([]byte, error) {
+	k, err := d.Peek()
+	if err != nil {
+		return nil, err
+	}
+	if k != TokenKey {
+		return nil, fmt.Errorf("Unexpected %q at this position, expected %q", k, TokenKey)
+	}
+	if d.p.cur_tok.Type != t_string {
+		return nil, d.fail(fmt.Errorf("Unexpected %q at this position, expected %q", tokennames[d.p.cur_tok.Type], tokennames[t_string]))
+	}
+	key := d.p.l.data[d.p.cur_tok.Start:d.p.cur_tok.End]
+	if d.p.cur_tok.Escaped {
+		d.buf = append(d.buf[:0], unescape(key)...)
+		key = d.buf
+	} else if d.p.l.r != nil {
+		// refilling the buffer while advancing overwrites the key
+		d.buf = append(d.buf[:0], key...)
+		key = d.buf
+	}
+	return key, d.colon()
+}
+
+// ReadString returns the next value, which has to be a string
+func (d *Decoder) ReadString() (string, error) {
+	if _, err := d.scalar(TokenString); err != nil {
+		return "", err
+	}
+	s := d.p.str()
+	return s, d.consume(nil)
+}
+
+// ReadBool returns the next value, which has to be true or false
+func (d *Decoder) ReadBool() (bool, error) {
+	if _, err := d.scalar(TokenBool); err != nil {
+		return false, err
+	}
+	b := d.p.cur_tok.Type == t_true
+	return b, d.consume(nil)
+}
+
+// ReadInt returns the next value, which has to be an integer fitting into
+// bitSize bits, see strconv.ParseInt. A number not fitting is consumed
+// nonetheless, the Decoder stays usable after all errors of the Read methods
+// caused by the type of the value.
+func (d *Decoder) ReadInt(bitSize int) (int64, error) {
+	raw, err := d.scalar(TokenNumber)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseInt(raw, 10, bitSize)
+	if err != nil {
+		err = fmt.Errorf("Number %s can not be represented as an int%s", raw, bitSuffix(bitSize))
+	}
+	return n, d.consume(err)
+}
+
+// ReadUint returns the next value, which has to be a non negative integer
+// fitting into bitSize bits, see strconv.ParseUint
+func (d *Decoder) ReadUint(bitSize int) (uint64, error) {
+	raw, err := d.scalar(TokenNumber)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseUint(raw, 10, bitSize)
+	if err != nil {
+		err = fmt.Errorf("Number %s can not be represented as an uint%s", raw, bitSuffix(bitSize))
+	}
+	return n, d.consume(err)
+}
+
+// ReadFloat returns the next value, which has to be a number, see
+// strconv.ParseFloat
+func (d *Decoder) ReadFloat(bitSize int) (float64, error) {
+	raw, err := d.scalar(TokenNumber)
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(raw, bitSize)
+	if err != nil {
+		err = fmt.Errorf("Invalid floating point number %q: %w", raw, err)
+	}
+	return f, d.consume(err)
+}
+
+// bitSuffix formats bitSize for error messages, 0 stands for int and uint
+func bitSuffix(bitSize int) string {
+	if bitSize == 0 {
+		return ""
+	}
+	return strconv.Itoa(bitSize)
+}
@@ -0,0 +1,243 @@
+package libjson
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecoderToken(t *testing.T) {
+	input := `{"key": "value", "arr": [1, true, false, null]}`
+	d := NewDecoder(strings.NewReader(input))
+	wanted := []Token{
+		{Kind: TokenObjectStart, Start: 0, End: 1},
+		{Kind: TokenKey, Value: "key", Start: 1, End: 6},
+		{Kind: TokenString, Value: "value", Start: 8, End: 15},
+		{Kind: TokenKey, Value: "arr", Start: 17, End: 22},
+		{Kind: TokenArrayStart, Start: 24, End: 25},
+		{Kind: TokenNumber, Value: 1.0, Start: 25, End: 26},
+		{Kind: TokenBool, Value: true, Start: 28, End: 32},
+		{Kind: TokenBool, Value: false, Start: 34, End: 39},
+		{Kind: TokenNull, Start: 41, End: 45},
+		{Kind: TokenArrayEnd, Start: 45, End: 46},
+		{Kind: TokenObjectEnd, Start: 46, End: 47},
+	}
+	for _, w := range wanted {
+		tok, err := d.Token()
+		assert.NoError(t, err)
+		assert.Equal(t, w, tok)
+		if w.Kind == TokenString || w.Kind == TokenKey {
+			assert.Equal(t, `"`+w.Value.(string)+`"`, input[tok.Start:tok.End])
+		}
+	}
+	_, err := d.Token()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestDecoderElements(t *testing.T) {
+	d := NewDecoder(strings.NewReader(`[{"id": 1, "skip": {"a": [1,2]}}, {"id": 2}, {"id": 3}]`))
+	tok, err := d.Token()
+	assert.NoError(t, err)
+	assert.Equal(t, TokenArrayStart, tok.Kind)
+
+	ids := []any{}
+	for d.More() {
+		v, err := d.Decode()
+		assert.NoError(t, err)
+		id, err := v.Root().Key("id").AsInt()
+		assert.NoError(t, err)
+		ids = append(ids, id)
+	}
+	assert.Equal(t, []any{1, 2, 3}, ids)
+
+	tok, err = d.Token()
+	assert.NoError(t, err)
+	assert.Equal(t, TokenArrayEnd, tok.Kind)
+	assert.False(t, d.More())
+}
+
+func TestDecoderSkip(t *testing.T) {
+	d := NewDecoder(strings.NewReader(`{"skip": {"a": [1, {"b": 2}]}, "keep": 1, "arr": [[1], 2]} 5`))
+	_, err := d.Token()
+	assert.NoError(t, err)
+
+	// skips the key and its value
+	assert.NoError(t, d.Skip())
+
+	tok, err := d.Token()
+	assert.NoError(t, err)
+	assert.Equal(t, "keep", tok.Value)
+	assert.NoError(t, d.Skip())
+
+	tok, err = d.Token()
+	assert.NoError(t, err)
+	assert.Equal(t, "arr", tok.Value)
+	_, err = d.Token()
+	assert.NoError(t, err)
+	assert.NoError(t, d.Skip())
+	v, err := d.Decode()
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, v.Root().Interface())
+	assert.Error(t, d.Skip())
+	assert.Error(t, d.Skip(), "errors are sticky")
+}
+
+func TestDecoderMultipleValues(t *testing.T) {
+	d := NewDecoder(strings.NewReader(`{} [] 1 "str"`))
+	got := []any{}
+	for d.More() {
+		v, err := d.Decode()
+		assert.NoError(t, err)
+		got = append(got, v.Root().Interface())
+	}
+	assert.Equal(t, []any{map[string]any{}, []any{}, 1.0, "str"}, got)
+	_, err := d.Decode()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestDecoderFail(t *testing.T) {
+	input := []string{
+		"[1,]",
+		"[1 2]",
+		`{"a" 1}`,
+		`{"a": 1,}`,
+		`{"a": 1 "b": 2}`,
+		`{1: 2}`,
+		"[",
+		"]",
+		"{]",
+	}
+	for _, in := range input {
+		t.Run(in, func(t *testing.T) {
+			d := NewDecoder(strings.NewReader(in))
+			var err error
+			for err == nil {
+				_, err = d.Token()
+			}
+			assert.NotErrorIs(t, err, io.EOF)
+		})
+	}
+}
+
+func TestDecoderRead(t *testing.T) {
+	input := `{"s": "str", "i": -12, "u": 12, "f": 1.5, "b": true, "n": null, "a": [1,2]}`
+	for name, d := range map[string]*Decoder{
+		"bytes":  NewDecoderBytes([]byte(input)),
+		"reader": NewDecoder(iotest.OneByteReader(strings.NewReader(input))),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := d.Expect(TokenObjectStart)
+			assert.NoError(t, err)
+
+			key := func(want string) {
+				k, err := d.Peek()
+				assert.NoError(t, err)
+				assert.Equal(t, TokenKey, k)
+				b, err := d.ReadKeyBytes()
+				assert.NoError(t, err)
+				assert.Equal(t, want, string(b))
+			}
+			key("s")
+			s, err := d.ReadString()
+			assert.NoError(t, err)
+			assert.Equal(t, "str", s)
+			key("i")
+			i, err := d.ReadInt(8)
+			assert.NoError(t, err)
+			assert.EqualValues(t, -12, i)
+			k, err := d.ReadKey()
+			assert.NoError(t, err)
+			assert.Equal(t, "u", k)
+			u, err := d.ReadUint(0)
+			assert.NoError(t, err)
+			assert.EqualValues(t, 12, u)
+			key("f")
+			// type mismatches do not consume the value
+			_, err = d.ReadString()
+			assert.Error(t, err)
+			f, err := d.ReadFloat(64)
+			assert.NoError(t, err)
+			assert.Equal(t, 1.5, f)
+			key("b")
+			null, err := d.Null()
+			assert.NoError(t, err)
+			assert.False(t, null)
+			b, err := d.ReadBool()
+			assert.NoError(t, err)
+			assert.True(t, b)
+			key("n")
+			null, err = d.Null()
+			assert.NoError(t, err)
+			assert.True(t, null)
+			key("a")
+			_, err = d.Expect(TokenObjectStart)
+			assert.Error(t, err)
+			assert.NoError(t, d.Skip())
+
+			k2, err := d.Peek()
+			assert.NoError(t, err)
+			assert.Equal(t, TokenObjectEnd, k2)
+			_, err = d.Expect(TokenObjectEnd)
+			assert.NoError(t, err)
+			assert.NoError(t, d.End())
+		})
+	}
+}
+
+func TestDecoderPeekTrailingComma(t *testing.T) {
+	for _, in := range []string{"[1,]", `{"a": 1,}`} {
+		d := NewDecoderBytes([]byte(in))
+		_, err := d.Token()
+		assert.NoError(t, err)
+		if in[0] == '{' {
+			_, err = d.ReadKey()
+			assert.NoError(t, err)
+		}
+		_, err = d.ReadInt(0)
+		assert.NoError(t, err)
+		assert.True(t, d.More())
+		_, err = d.Peek()
+		if err == nil {
+			// the comma was consumed, the closing bracket is not accepted
+			_, err = d.Token()
+		}
+		assert.Error(t, err)
+	}
+}
+
+func TestDecoderReadFail(t *testing.T) {
+	d := NewDecoderBytes([]byte(`[300, 1.5, -1] 1`))
+	_, err := d.Token()
+	assert.NoError(t, err)
+	_, err = d.ReadInt(8)
+	assert.Error(t, err)
+	_, err = d.ReadInt(0)
+	assert.Error(t, err)
+	_, err = d.ReadUint(0)
+	assert.Error(t, err)
+	_, err = d.Token()
+	assert.NoError(t, err)
+	assert.Error(t, d.End())
+}
+
+func TestDecoderReadEscaped(t *testing.T) {
+	input := `{"a\"b\n": "c\\d\u00e4"}`
+	for name, d := range map[string]*Decoder{
+		"bytes":  NewDecoderBytes([]byte(input)),
+		"reader": NewDecoder(iotest.OneByteReader(strings.NewReader(input))),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := d.Expect(TokenObjectStart)
+			assert.NoError(t, err)
+			k, err := d.ReadKeyBytes()
+			assert.NoError(t, err)
+			assert.Equal(t, "a\"b\n", string(k))
+			s, err := d.ReadString()
+			assert.NoError(t, err)
+			assert.Equal(t, "c\\dä", s)
+		})
+	}
+}
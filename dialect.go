@@ -0,0 +1,391 @@
+package libjson
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// Dialect selects the syntax accepted by the parser, see UseDialect
+type Dialect uint8
+
+const (
+	// Strict accepts only json as specified by rfc8259, the default
+	Strict Dialect = iota
+	// JSONC extends Strict with line (//) and block (/* */) comments and
+	// trailing commas in arrays and objects, as used by the configuration
+	// files of VS Code and TypeScript
+	JSONC
+	// JSON5 extends JSONC with the syntax of https://spec.json5.org:
+	// identifiers as object keys, single quoted strings, escapes including
+	// line continuations in strings, hexadecimal numbers, leading and
+	// trailing decimal points, explicit plus signs, Infinity and NaN as well
+	// as additional whitespace
+	JSON5
+)
+
+// UseDialect makes New, NewReader and Parser accept d instead of strict json.
+// The structural index is only used for strict json, thus the other dialects
+// are lexed byte by byte and Parallel has no effect. NewLazy and the Decoder
+// always expect strict json.
+func UseDialect(d Dialect) Option {
+	return func(c *config) {
+		c.dialect = d
+	}
+}
+
+// peek returns the byte at l.pos without consuming it
+func (l *lexer) peek() (byte, bool) {
+	if !l.ensure(1) {
+		return 0, false
+	}
+	return l.data[l.pos], true
+}
+
+// trivia skips the whitespace and comments of JSONC and JSON5 starting with
+// cc, returning the first byte following them
+func (l *lexer) trivia(cc byte) (byte, error) {
+	for {
+		switch {
+		case cc == ' ' || cc == '\n' || cc == '\t' || cc == '\r':
+		case cc == '/':
+			if err := l.comment(); err != nil {
+				return 0, err
+			}
+		case l.dialect == JSON5 && l.space(cc):
+		default:
+			return cc, nil
+		}
+		// whitespace and comments are never part of a token, thus a
+		// refill may discard them
+		l.start = l.pos
+		var err error
+		cc, err = l.advance()
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// comment skips a line or block comment, the leading '/' is already consumed
+func (l *lexer) comment() error {
+	cc, err := l.advance()
+	if err != nil {
+		if err = l.readErr(err); err != nil {
+			return err
+		}
+		return errors.New("Unexpected end of input after '/', expected a comment")
+	}
+	switch cc {
+	case '/':
+		for {
+			cc, err = l.advance()
+			if err != nil {
+				// a line comment may end the input
+				return l.readErr(err)
+			} else if cc == '\n' {
+				return nil
+			}
+		}
+	case '*':
+		var prev byte
+		for {
+			cc, err = l.advance()
+			if err != nil {
+				if err = l.readErr(err); err != nil {
+					return err
+				}
+				return errors.New("Unterminated block comment detected")
+			} else if prev == '*' && cc == '/' {
+				return nil
+			}
+			prev = cc
+		}
+	default:
+		return fmt.Errorf("Unexpected character %q after '/', expected a comment", cc)
+	}
+}
+
+// space reports whether cc starts one of the additional whitespace
+// characters of JSON5 and consumes it: vertical tab, form feed, no-break
+// space, byte order mark, line and paragraph separator
+func (l *lexer) space(cc byte) bool {
+	switch cc {
+	case '\v', '\f':
+		return true
+	case 0xC2: // U+00A0
+		if l.ensure(1) && l.data[l.pos] == 0xA0 {
+			l.pos++
+			return true
+		}
+	case 0xEF: // U+FEFF
+		if l.ensure(2) && l.data[l.pos] == 0xBB && l.data[l.pos+1] == 0xBF {
+			l.pos += 2
+			return true
+		}
+	case 0xE2: // U+2028, U+2029
+		if l.ensure(2) && l.data[l.pos] == 0x80 && (l.data[l.pos+1] == 0xA8 || l.data[l.pos+1] == 0xA9) {
+			l.pos += 2
+			return true
+		}
+	}
+	return false
+}
+
+// json5 lexes the tokens JSON5 extends or adds: strings, numbers and
+// identifiers, reporting false for any other token
+func (l *lexer) json5(cc byte) (token, bool, error) {
+	switch {
+	case cc == '"' || cc == '\'':
+		t, err := l.quoted(cc)
+		return t, true, err
+	case cc == '+' || cc == '-' || cc == '.' || (cc >= '0' && cc <= '9'):
+		t, err := l.number(cc)
+		return t, true, err
+	case identStart(cc):
+		return l.ident(), true, nil
+	}
+	return empty, false, nil
+}
+
+// quoted lexes a string delimited by q, validating its escapes, which are
+// resolved by the parser, see unescape
+func (l *lexer) quoted(q byte) (token, error) {
+	escaped := false
+	for {
+		cc, err := l.advance()
+		if err != nil {
+			if err = l.readErr(err); err != nil {
+				return empty, err
+			}
+			return empty, errors.New("Unterminated string detected")
+		}
+		switch cc {
+		case q:
+			return token{Type: t_string, Start: l.start + 1, End: l.pos - 1, Escaped: escaped}, nil
+		case '\n', '\r':
+			return empty, errors.New("Unescaped line break in string, escape it with '\\' to continue the string on the next line")
+		case '\\':
+			escaped = true
+			cc, err = l.advance()
+			if err != nil {
+				if err = l.readErr(err); err != nil {
+					return empty, err
+				}
+				return empty, errors.New("Unterminated string detected")
+			}
+			n := 0
+			switch {
+			case cc == '\r':
+				// \r\n is a single line break
+				if c, ok := l.peek(); ok && c == '\n' {
+					l.pos++
+				}
+			case cc == 'x':
+				n = 2
+			case cc == 'u':
+				n = 4
+			case cc >= '1' && cc <= '9':
+				return empty, fmt.Errorf("Invalid escape '\\%c' in string", cc)
+			}
+			if !l.ensure(n) {
+				return empty, errors.New("Unterminated string detected")
+			}
+			for _, h := range l.data[l.pos : l.pos+n] {
+				if !isHex(h) {
+					return empty, fmt.Errorf("Invalid escape '\\%c', expected %d hexadecimal digits", cc, n)
+				}
+			}
+			l.pos += n
+		}
+	}
+}
+
+// number lexes a decimal or hexadecimal number, Infinity or NaN, each with an
+// optional sign, strconv validates decimal numbers once parsed
+func (l *lexer) number(cc byte) (token, error) {
+	if cc == '+' || cc == '-' {
+		c, ok := l.peek()
+		if !ok {
+			return empty, errors.New("Unexpected end of input after sign, expected a number")
+		}
+		if identStart(c) {
+			l.pos++
+			l.word()
+			if w := l.data[l.start+1 : l.pos]; string(w) != "Infinity" && string(w) != "NaN" {
+				return empty, fmt.Errorf("Unexpected %q after sign, expected a number, Infinity or NaN", w)
+			}
+			return token{Type: t_number, Start: l.start, End: l.pos}, nil
+		}
+		if c != '.' && (c < '0' || c > '9') {
+			// the sign alone is not a number, this is reported by the parser
+			return token{Type: t_number, Start: l.start, End: l.pos}, nil
+		}
+		cc = c
+		l.pos++
+	}
+	hex := false
+	if cc == '0' {
+		if c, ok := l.peek(); ok && (c == 'x' || c == 'X') {
+			l.pos++
+			hex = true
+		}
+	}
+	for {
+		c, ok := l.peek()
+		if !ok {
+			break
+		}
+		if hex && !isHex(c) {
+			break
+		}
+		if !hex && !((c >= '0' && c <= '9') || c == '-' || c == '+' || c == '.' || c == 'e' || c == 'E') {
+			break
+		}
+		l.pos++
+	}
+	return token{Type: t_number, Start: l.start, End: l.pos}, nil
+}
+
+// ident lexes an identifier, whose first byte is already consumed, keywords
+// are lexed as their respective token, keeping their offsets since JSON5
+// allows them as object keys, see parser.identifier
+func (l *lexer) ident() token {
+	l.word()
+	switch string(l.data[l.start:l.pos]) {
+	case "true":
+		return token{Type: t_true, Start: l.start, End: l.pos}
+	case "false":
+		return token{Type: t_false, Start: l.start, End: l.pos}
+	case "null":
+		return token{Type: t_null, Start: l.start, End: l.pos}
+	case "Infinity", "NaN":
+		return token{Type: t_number, Start: l.start, End: l.pos}
+	}
+	return token{Type: t_ident, Start: l.start, End: l.pos}
+}
+
+// word consumes the remaining bytes of an identifier
+func (l *lexer) word() {
+	for {
+		c, ok := l.peek()
+		if !ok || !(identStart(c) || (c >= '0' && c <= '9')) {
+			return
+		}
+		l.pos++
+	}
+}
+
+// identifier reports whether the current token is an identifier, including
+// keywords, thus a valid JSON5 object key
+func (p *parser) identifier() bool {
+	t := p.cur_tok
+	return t.Type == t_ident || (p.l.dialect == JSON5 && t.End > t.Start && t.Type != t_string && identStart(p.l.data[t.Start]))
+}
+
+// parseNumber5 parses the numbers lexed by lexer.number, strconv.ParseFloat
+// neither accepts hexadecimal integers nor signed NaN
+func parseNumber5(raw string) (float64, error) {
+	if strings.ContainsAny(raw, "xX") {
+		i, err := strconv.ParseInt(raw, 0, 64)
+		return float64(i), err
+	}
+	if len(raw) == 4 && raw[1:] == "NaN" {
+		return math.NaN(), nil
+	}
+	return strconv.ParseFloat(raw, 64)
+}
+
+// identStart reports whether c may start an identifier, bytes of multibyte
+// utf8 sequences are accepted as is
+func identStart(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_' || c == '$' || c >= utf8.RuneSelf
+}
+
+func isHex(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// unhex decodes the hexadecimal digits of in, which are validated by the
+// lexer
+func unhex(in string) rune {
+	var r rune
+	for i := 0; i < len(in); i++ {
+		c := in[i]
+		switch {
+		case c >= '0' && c <= '9':
+			c -= '0'
+		case c >= 'a' && c <= 'f':
+			c -= 'a' - 10
+		default:
+			c -= 'A' - 10
+		}
+		r = r<<4 | rune(c)
+	}
+	return r
+}
+
+// unescape resolves the escapes of a JSON5 string, a backslash followed by a
+// line break continues the string on the next line
+func unescape(in []byte) string {
+	s := *(*string)(unsafe.Pointer(&in))
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		c = s[i]
+		switch c {
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'v':
+			b.WriteByte('\v')
+		case '0':
+			b.WriteByte(0)
+		case 'x':
+			b.WriteRune(unhex(s[i+1 : i+3]))
+			i += 2
+		case 'u':
+			r := unhex(s[i+1 : i+5])
+			i += 4
+			// surrogate pairs are encoded as two escapes
+			if r >= 0xD800 && r < 0xDC00 && i+6 < len(s) && s[i+1] == '\\' && s[i+2] == 'u' {
+				if lo := unhex(s[i+3 : i+7]); lo >= 0xDC00 && lo < 0xE000 {
+					r = 0x10000 + (r-0xD800)<<10 + (lo - 0xDC00)
+					i += 6
+				}
+			}
+			b.WriteRune(r)
+		case '\r':
+			if i+1 < len(s) && s[i+1] == '\n' {
+				i++
+			}
+		case '\n':
+		default:
+			// U+2028 and U+2029 continue the string as well
+			if strings.HasPrefix(s[i:], "\u2028") || strings.HasPrefix(s[i:], "\u2029") {
+				i += 2
+				continue
+			}
+			// any other character escapes itself, such as quotes
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
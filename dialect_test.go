@@ -0,0 +1,154 @@
+package libjson
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialectJSONC(t *testing.T) {
+	input := map[string]any{
+		"// leading\n[1, 2, 3,]":                   []any{1.0, 2.0, 3.0},
+		`{"a": 1, /* inline */ "b": [true,],}`:     map[string]any{"a": 1.0, "b": []any{true}},
+		"{\"a\": \"//not a comment\"} // trailing": map[string]any{"a": "//not a comment"},
+		"/* multi\n * line\n */ null /**/":         nil,
+		"[\n\t1, // one\n\t2 /* two */\n]":         []any{1.0, 2.0},
+		`{"a":/***/1}`:                             map[string]any{"a": 1.0},
+		"1//":                                      1.0,
+	}
+	for in, want := range input {
+		t.Run(in, func(t *testing.T) {
+			j, err := New([]byte(in), UseDialect(JSONC))
+			assert.NoError(t, err)
+			assert.EqualValues(t, want, j.obj)
+
+			j, err = NewReader(iotest.OneByteReader(bytes.NewReader([]byte(in))), UseDialect(JSONC))
+			assert.NoError(t, err)
+			assert.EqualValues(t, want, j.obj)
+
+			_, err = New([]byte(in))
+			assert.Error(t, err, "strict json accepted the input")
+		})
+	}
+}
+
+func TestDialectJSON5(t *testing.T) {
+	input := map[string]any{
+		`{unquoted: 'and you can quote me on that',}`:        map[string]any{"unquoted": "and you can quote me on that"},
+		`{'singleQuotes': 'I can use "double quotes" here'}`: map[string]any{"singleQuotes": `I can use "double quotes" here`},
+		`{lineBreaks: "Look, Mom! \
+No \\n's!"}`: map[string]any{"lineBreaks": `Look, Mom! No \n's!`},
+		"'crlf \\\r\ncontinued'":              "crlf continued",
+		"'ls \\\u2028continued'":              "ls continued",
+		`{hexadecimal: 0xdecaf, neg: -0XFF}`:  map[string]any{"hexadecimal": 912559.0, "neg": -255.0},
+		`[.8675309, 8675309., +1, -.5]`:       []any{.8675309, 8675309.0, 1.0, -.5},
+		`{$id_1: 1, _: 2, ünï: 3}`:            map[string]any{"$id_1": 1.0, "_": 2.0, "ünï": 3.0},
+		`'\'\"\\\b\f\n\r\t\v\0\x41é😀\a'`:      "'\"\\\b\f\n\r\t\v\x00Aé😀a",
+		`{nested: {true: true, null: null}}`:  map[string]any{"nested": map[string]any{"true": true, "null": nil}},
+		"\v\f\u00a0\ufeff\u2028[ 1 ]":         []any{1.0},
+		`[Infinity, -Infinity, +Infinity]`:    []any{math.Inf(1), math.Inf(-1), math.Inf(1)},
+		"/* comment */ {a: 1, // trailing\n}": map[string]any{"a": 1.0},
+	}
+	for in, want := range input {
+		t.Run(in, func(t *testing.T) {
+			j, err := New([]byte(in), UseDialect(JSON5))
+			assert.NoError(t, err)
+			assert.EqualValues(t, want, j.obj)
+
+			j, err = NewReader(iotest.OneByteReader(bytes.NewReader([]byte(in))), UseDialect(JSON5))
+			assert.NoError(t, err)
+			assert.EqualValues(t, want, j.obj)
+
+			_, err = New([]byte(in))
+			assert.Error(t, err, "strict json accepted the input")
+		})
+	}
+
+	t.Run("NaN", func(t *testing.T) {
+		j, err := New([]byte(`[NaN, -NaN, +NaN]`), UseDialect(JSON5))
+		assert.NoError(t, err)
+		for _, v := range j.obj.([]any) {
+			assert.True(t, math.IsNaN(v.(float64)))
+		}
+	})
+
+	t.Run("interned escaped keys", func(t *testing.T) {
+		j, err := New([]byte(`{'a\'b': 1, "cd": 2}`), UseDialect(JSON5), InternKeys())
+		assert.NoError(t, err)
+		assert.EqualValues(t, map[string]any{"a'b": 1.0, "cd": 2.0}, j.obj)
+	})
+}
+
+func TestDialectFail(t *testing.T) {
+	input := map[Dialect][]string{
+		Strict: {
+			`[1,]`,
+			`{"a":1,}`,
+			`// comment`,
+		},
+		JSONC: {
+			`{a: 1}`,
+			`'single'`,
+			`0x10`,
+			`[1,,]`,
+			`[,]`,
+			`{,}`,
+			`/* unterminated`,
+			`1 /`,
+			`1 /# comment`,
+		},
+		JSON5: {
+			`[1,,]`,
+			`{a 1}`,
+			"'line\nbreak'",
+			`'unterminated`,
+			`'\1'`,
+			`'\x4'`,
+			`'\u12G4'`,
+			`+Inf`,
+			`-`,
+			`[-]`,
+			`[+ 1]`,
+			`0x`,
+			`1.2.3`,
+			`{a-b: 1}`,
+			`[key]`,
+		},
+	}
+	for d, in := range input {
+		for _, i := range in {
+			t.Run(i, func(t *testing.T) {
+				_, err := New([]byte(i), UseDialect(d))
+				assert.Error(t, err)
+				_, err = NewReader(bytes.NewReader([]byte(i)), UseDialect(d))
+				assert.Error(t, err)
+			})
+		}
+	}
+
+	t.Run("sign", func(t *testing.T) {
+		// the closing bracket is not lexed as part of the number
+		_, err := New([]byte(`[-]`), UseDialect(JSON5))
+		assert.ErrorContains(t, err, `Invalid floating point number "-"`)
+	})
+}
+
+func TestDialectParser(t *testing.T) {
+	p := NewParser(UseDialect(JSONC))
+	for range 2 {
+		j, err := p.Parse([]byte(`[1, /* two */ 2,]`))
+		assert.NoError(t, err)
+		assert.EqualValues(t, []any{1.0, 2.0}, j.obj)
+		j, err = p.ParseReader(bytes.NewReader([]byte(`{"a": 1,} // done`)))
+		assert.NoError(t, err)
+		assert.EqualValues(t, map[string]any{"a": 1.0}, j.obj)
+	}
+
+	// Parallel requires the structural index and is thus ignored
+	j, err := New([]byte(`[1, 2, 3, /**/]`), UseDialect(JSONC), Parallel(4))
+	assert.NoError(t, err)
+	assert.EqualValues(t, []any{1.0, 2.0, 3.0}, j.obj)
+}
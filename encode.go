@@ -0,0 +1,181 @@
+package libjson
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Marshaler is implemented by types encoding themselves, such as the types
+// generated by cmd/libjson-gen
+type Marshaler interface {
+	MarshalLibJSON() ([]byte, error)
+}
+
+// Unmarshaler is implemented by types decoding themselves, such as the types
+// generated by cmd/libjson-gen
+type Unmarshaler interface {
+	UnmarshalLibJSON(data []byte) error
+}
+
+const hex = "0123456789abcdef"
+
+// AppendString appends s as a quoted json string to buf, escaping quotes,
+// backslashes and control characters, invalid utf8 is replaced by U+FFFD
+func AppendString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		b := s[i]
+		if b >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && size == 1 {
+				buf = append(buf, s[start:i]...)
+				buf = append(buf, "\ufffd"...)
+				i += size
+				start = i
+				continue
+			}
+			i += size
+			continue
+		}
+		if b >= ' ' && b != '"' && b != '\\' {
+			i++
+			continue
+		}
+		buf = append(buf, s[start:i]...)
+		switch b {
+		case '"', '\\':
+			buf = append(buf, '\\', b)
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		case '\r':
+			buf = append(buf, '\\', 'r')
+		case '\t':
+			buf = append(buf, '\\', 't')
+		case '\b':
+			buf = append(buf, '\\', 'b')
+		case '\f':
+			buf = append(buf, '\\', 'f')
+		default:
+			buf = append(buf, '\\', 'u', '0', '0', hex[b>>4], hex[b&0xF])
+		}
+		i++
+		start = i
+	}
+	buf = append(buf, s[start:]...)
+	return append(buf, '"')
+}
+
+// AppendFloat appends f to buf in the shortest representation parsing back
+// to the same float of bitSize bits, using exponents only for very small and
+// very large numbers, matching encoding/json. Json has no representation for
+// NaN and the infinities, thus these are rejected, see NonFinite.AppendFloat
+// for the alternatives.
+func AppendFloat(buf []byte, f float64, bitSize int) ([]byte, error) {
+	return NonFiniteError.AppendFloat(buf, f, bitSize)
+}
+
+// AppendFloat is the package level AppendFloat, encoding NaN and the
+// infinities according to n
+func (n NonFinite) AppendFloat(buf []byte, f float64, bitSize int) ([]byte, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		switch n {
+		case NonFiniteNull:
+			return append(buf, "null"...), nil
+		case NonFiniteLiteral:
+			if math.IsNaN(f) {
+				return append(buf, "NaN"...), nil
+			} else if f > 0 {
+				return append(buf, "Infinity"...), nil
+			}
+			return append(buf, "-Infinity"...), nil
+		}
+		return buf, fmt.Errorf("Unsupported number %v, json has no representation for non-finite numbers", f)
+	}
+	abs := math.Abs(f)
+	format := byte('f')
+	if abs != 0 {
+		if bitSize == 64 && (abs < 1e-6 || abs >= 1e21) || bitSize == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	buf = strconv.AppendFloat(buf, f, format, -1, bitSize)
+	if format == 'e' {
+		// 1e-07 to 1e-7
+		n := len(buf)
+		if n >= 4 && buf[n-4] == 'e' && buf[n-3] == '-' && buf[n-2] == '0' {
+			buf[n-2] = buf[n-1]
+			buf = buf[:n-1]
+		}
+	}
+	return buf, nil
+}
+
+// Append appends the json encoding of j to buf. As for MarshalJSON, object
+// members are sorted by their keys, but strings are not escaped for html, see
+// AppendString. Non-finite numbers are encoded as configured via
+// EncodeNonFinite.
+func (j *JSON) Append(buf []byte, opts ...Option) ([]byte, error) {
+	c := newConfig(opts)
+	return appendValue(buf, j.obj, &c)
+}
+
+func appendValue(buf []byte, v any, c *config) ([]byte, error) {
+	var err error
+	switch v := v.(type) {
+	case nil:
+		return append(buf, "null"...), nil
+	case bool:
+		return strconv.AppendBool(buf, v), nil
+	case string:
+		if c.canonical {
+			return appendCanonicalString(buf, v)
+		}
+		return AppendString(buf, v), nil
+	case float64:
+		if c.canonical {
+			return appendCanonicalFloat(buf, v)
+		}
+		return c.nonFinite.AppendFloat(buf, v, 64)
+	case []any:
+		buf = append(buf, '[')
+		for i, e := range v {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			if buf, err = appendValue(buf, e, c); err != nil {
+				return buf, err
+			}
+		}
+		return append(buf, ']'), nil
+	case map[string]any:
+		buf = append(buf, '{')
+		keys := slices.Sorted(maps.Keys(v))
+		if c.canonical {
+			slices.SortFunc(keys, compareUTF16)
+		}
+		for i, k := range keys {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			if c.canonical {
+				if buf, err = appendCanonicalString(buf, k); err != nil {
+					return buf, err
+				}
+			} else {
+				buf = AppendString(buf, k)
+			}
+			buf = append(buf, ':')
+			if buf, err = appendValue(buf, v[k], c); err != nil {
+				return buf, err
+			}
+		}
+		return append(buf, '}'), nil
+	default:
+		return buf, fmt.Errorf("Unsupported value of type %T", v)
+	}
+}
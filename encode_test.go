@@ -0,0 +1,95 @@
+package libjson
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendString(t *testing.T) {
+	input := []string{"", "abc", `"quoted"`, "back\\slash", "\n\r\t\b\f", "\x00\x1f", "äöü", "\xff invalid", "日本"}
+	for _, i := range input {
+		want, err := json.Marshal(i)
+		assert.NoError(t, err)
+		assert.Equal(t, string(want), string(AppendString(nil, i)))
+	}
+}
+
+func TestAppendFloat(t *testing.T) {
+	input := []float64{0, -0.5, 1, 1.5, 1e20, 1e21, 1e-6, 1e-7, 123456789, math.MaxFloat64, math.SmallestNonzeroFloat64}
+	for _, i := range input {
+		want, err := json.Marshal(i)
+		assert.NoError(t, err)
+		got, err := AppendFloat(nil, i, 64)
+		assert.NoError(t, err)
+		assert.Equal(t, string(want), string(got))
+
+		if f := float32(i); !math.IsInf(float64(f), 0) {
+			want, err = json.Marshal(f)
+			assert.NoError(t, err)
+			got, err = AppendFloat(nil, float64(f), 32)
+			assert.NoError(t, err)
+			assert.Equal(t, string(want), string(got))
+		}
+	}
+	for _, i := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		_, err := AppendFloat(nil, i, 64)
+		assert.Error(t, err)
+	}
+}
+
+func TestNonFiniteAppendFloat(t *testing.T) {
+	input := []float64{math.NaN(), math.Inf(1), math.Inf(-1)}
+	wanted := map[NonFinite][]string{
+		NonFiniteNull:    {"null", "null", "null"},
+		NonFiniteLiteral: {"NaN", "Infinity", "-Infinity"},
+	}
+	for n, want := range wanted {
+		for i, f := range input {
+			got, err := n.AppendFloat(nil, f, 64)
+			assert.NoError(t, err)
+			assert.Equal(t, want[i], string(got))
+		}
+	}
+	for _, f := range input {
+		_, err := NonFiniteError.AppendFloat(nil, f, 64)
+		assert.Error(t, err)
+	}
+	got, err := NonFiniteLiteral.AppendFloat(nil, 1.5, 64)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.5", string(got))
+}
+
+func TestJSONAppend(t *testing.T) {
+	input := []string{
+		`{"b": [1, 2.5, "three", true, false, null], "a": {"z": {}, "y": []}}`,
+		`"str"`,
+		`-1e-7`,
+		`[{"kä": "ü"}]`,
+	}
+	for _, i := range input {
+		t.Run(i, func(t *testing.T) {
+			j, err := New([]byte(i))
+			assert.NoError(t, err)
+			want, err := j.MarshalJSON()
+			assert.NoError(t, err)
+			got, err := j.Append(nil)
+			assert.NoError(t, err)
+			assert.Equal(t, string(want), string(got))
+		})
+	}
+
+	j, err := New([]byte(`[NaN, -Infinity, 1]`), AllowNonFinite())
+	assert.NoError(t, err)
+	_, err = j.Append(nil)
+	assert.Error(t, err)
+	got, err := j.Append(nil, EncodeNonFinite(NonFiniteNull))
+	assert.NoError(t, err)
+	assert.Equal(t, `[null,null,1]`, string(got))
+	got, err = j.Append(nil, EncodeNonFinite(NonFiniteLiteral))
+	assert.NoError(t, err)
+	assert.Equal(t, `[NaN,-Infinity,1]`, string(got))
+
+}
@@ -0,0 +1,180 @@
+package libjson
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// The structural index is computed in the style of simdjson stage 1, but
+// instead of SIMD instructions it uses SWAR (SIMD within a register): eight
+// bytes are loaded into a single uint64 and classified at once, the results
+// of eight of those words are combined into 64 bit masks with one bit per
+// input byte. The second stage, see lexer.next, uses the index to jump from
+// token to token instead of scanning the input byte by byte.
+
+const (
+	lsb = 0x0101010101010101
+	msb = 0x8080808080808080
+)
+
+// eq sets the high bit of every byte of w equal to c
+func eq(w uint64, c byte) uint64 {
+	x := w ^ (lsb * uint64(c))
+	// high bit is set for all non zero bytes, without carries crossing bytes
+	nonzero := ((x &^ msb) + ^uint64(msb)) | x
+	return ^nonzero & msb
+}
+
+// gather compresses the high bits of the eight bytes of m into a byte
+func gather(m uint64) uint64 {
+	return ((m >> 7) * 0x0102040810204080) >> 56
+}
+
+// prefixXor computes the running xor of all bits of m, thus every bit
+// between an opening and a closing quote is set
+func prefixXor(m uint64) uint64 {
+	m ^= m << 1
+	m ^= m << 2
+	m ^= m << 4
+	m ^= m << 8
+	m ^= m << 16
+	m ^= m << 32
+	return m
+}
+
+// blockMasks classifies the 64 bytes of block, returning one bit per byte for
+// quotes, structural characters ({}[],:), whitespace and backslashes
+func blockMasks(block []byte) (quote uint64, op uint64, ws uint64, bs uint64) {
+	_ = block[63]
+	for i := 0; i < 8; i++ {
+		w := binary.LittleEndian.Uint64(block[i*8:])
+		// '[' | 0x20 == '{' and ']' | 0x20 == '}', no other byte maps to them
+		lower := w | (lsb * 0x20)
+		o := eq(lower, '{') | eq(lower, '}') | eq(w, ',') | eq(w, ':')
+		s := eq(w, ' ') | eq(w, '\n') | eq(w, '\t') | eq(w, '\r')
+		shift := uint(i * 8)
+		quote |= gather(eq(w, '"')) << shift
+		op |= gather(o) << shift
+		ws |= gather(s) << shift
+		bs |= gather(eq(w, '\\')) << shift
+	}
+	return
+}
+
+const evenBits = 0x5555555555555555
+
+// escapedMask returns a bit for every byte of a block following an odd
+// amount of backslashes, thus escaped, given the backslashes bs of the
+// block. prev is set if the first byte of the block is escaped by the
+// previous block and is updated for the next one.
+func escapedMask(bs uint64, prev *uint64) uint64 {
+	// an escaped backslash does not escape the following byte
+	bs &^= *prev
+	followsEscape := bs<<1 | *prev
+	// adding the starts of runs on odd bits carries them past their end,
+	// leaving only the runs starting on even bits
+	oddStarts := bs &^ evenBits &^ followsEscape
+	evenRuns, carry := bits.Add64(oddStarts, bs, 0)
+	*prev = carry
+	// every other byte following the start of a run is escaped, inverted
+	// for runs starting on even bits
+	return (evenBits ^ evenRuns<<1) & followsEscape
+}
+
+// size of the chunks of the input indexed at once, keeps the memory usage of
+// the index constant instead of proportional to the input
+const indexChunk = 64 * 1024
+
+// structuralIndex holds the offsets of all structural characters, of all
+// quotes and of the first byte of every scalar outside of strings for a
+// chunk of the input. Strings end at the first quote after their start not
+// escaped by a backslash, matching lexer.next.
+type structuralIndex struct {
+	// offsets relative to base
+	offsets []uint32
+	i       int
+	base    int
+	// start of the next chunk
+	off int
+	// all ones if the previous block ended inside of a string
+	inString uint64
+	// the last byte of the previous block was part of a scalar
+	prevScalar uint64
+	// the first byte of the next block is escaped, see escapedMask
+	escaped uint64
+}
+
+// newStructuralIndex sizes the index for an input of size bytes, thus small
+// inputs do not pay for a whole chunk
+func newStructuralIndex(size int) *structuralIndex {
+	return &structuralIndex{offsets: make([]uint32, 0, min(size, indexChunk)/4)}
+}
+
+// reset prepares s for indexing a new input, keeping its buffer
+func (s *structuralIndex) reset() {
+	*s = structuralIndex{offsets: s.offsets[:0]}
+}
+
+// next returns the offset of the next structural character in data,
+// indexing the next chunk if the current one is exhausted
+func (s *structuralIndex) next(data []byte) (int, bool) {
+	if s.i >= len(s.offsets) && !s.scan(data) {
+		return 0, false
+	}
+	o := s.offsets[s.i]
+	s.i++
+	return s.base + int(o), true
+}
+
+// scan indexes the chunks of data starting at s.off until at least a single
+// offset is found, reports whether one was found
+func (s *structuralIndex) scan(data []byte) bool {
+	s.offsets = s.offsets[:0]
+	s.i = 0
+	s.base = s.off
+	end := min(s.off+indexChunk, len(data))
+	var block [64]byte
+	for ; s.off < len(data) && (s.off < end || len(s.offsets) == 0); s.off += 64 {
+		var chunk []byte
+		if len(data)-s.off >= 64 {
+			chunk = data[s.off : s.off+64]
+		} else {
+			// pad the last block with whitespace
+			n := copy(block[:], data[s.off:])
+			for i := n; i < 64; i++ {
+				block[i] = ' '
+			}
+			chunk = block[:]
+		}
+
+		quote, op, ws, bs := blockMasks(chunk)
+		if bs != 0 || s.escaped != 0 {
+			quote &^= escapedMask(bs, &s.escaped)
+		}
+		str := prefixXor(quote) ^ s.inString
+		s.inString = uint64(int64(str) >> 63)
+
+		scalar := ^(op | ws | quote | str)
+		scalarStart := scalar &^ (scalar<<1 | s.prevScalar)
+		s.prevScalar = scalar >> 63
+
+		mask := (op &^ str) | quote | scalarStart
+		rel := uint32(s.off - s.base)
+		for mask != 0 {
+			s.offsets = append(s.offsets, rel+uint32(bits.TrailingZeros64(mask)))
+			mask &= mask - 1
+		}
+	}
+	return len(s.offsets) > 0
+}
+
+// delimiter reports whether b may follow a scalar, used to detect trailing
+// bytes the structural index does not point to
+func delimiter(b byte) bool {
+	switch b {
+	case ' ', '\n', '\t', '\r', '{', '}', '[', ']', ',', ':', '"':
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,120 @@
+package libjson
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndexBlockMasks(t *testing.T) {
+	alphabet := []byte("{}[],:\" \n\t\rab01-.\\\x00\xff\x7b\x5b\x7d\x5d")
+	r := rand.New(rand.NewSource(0))
+	block := make([]byte, 64)
+	for range 1000 {
+		for i := range block {
+			block[i] = alphabet[r.Intn(len(alphabet))]
+		}
+		quote, op, ws, bs := blockMasks(block)
+		for i, b := range block {
+			bit := uint64(1) << i
+			assert.Equal(t, b == '"', quote&bit != 0)
+			assert.Equal(t, strings.IndexByte("{}[],:", b) != -1, op&bit != 0, "%q", b)
+			assert.Equal(t, strings.IndexByte(" \n\t\r", b) != -1, ws&bit != 0)
+			assert.Equal(t, b == '\\', bs&bit != 0)
+		}
+	}
+}
+
+func TestIndexEscapedMask(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	data := make([]byte, 64*8)
+	for range 1000 {
+		for i := range data {
+			data[i] = "\\\\\\a"[r.Intn(4)]
+		}
+		// a byte is escaped if it follows an odd amount of backslashes
+		want := make([]bool, len(data))
+		for i, run := 0, 0; i < len(data); i++ {
+			want[i] = run%2 == 1
+			if data[i] == '\\' && !want[i] {
+				run++
+			} else {
+				run = 0
+			}
+		}
+		prev := uint64(0)
+		for off := 0; off < len(data); off += 64 {
+			_, _, _, bs := blockMasks(data[off : off+64])
+			escaped := escapedMask(bs, &prev)
+			for i := range 64 {
+				assert.Equal(t, want[off+i], escaped&(1<<i) != 0, "offset %d", off+i)
+			}
+		}
+	}
+}
+
+// lexAll collects all tokens as a string representation until the first
+// error or t_eof
+func lexAll(l *lexer) ([]string, error) {
+	toks := []string{}
+	for {
+		tok, err := l.next()
+		if err != nil {
+			return toks, err
+		}
+		if tok.Type == t_eof {
+			return toks, nil
+		}
+		toks = append(toks, tokennames[tok.Type]+string(l.data[tok.Start:tok.End]))
+	}
+}
+
+func TestIndexLexer(t *testing.T) {
+	input := []string{
+		"",
+		"   ",
+		"{}[],:",
+		`{"key": "value", "arr": [1, -2.5e10, true, false, null]}`,
+		`"string""" "🤣" "{[,:]}"`,
+		"1 0 12.5 1e15 -1929 -0 -1.4E+5",
+		strings.Repeat(" ", 63) + `"crosses a block"` + strings.Repeat("x", 70),
+		`"` + strings.Repeat("a", indexChunk+100) + `" 1`,
+		strings.Repeat(`{"a": [1, "b", true]}, `, indexChunk/8),
+		`["a\"b", "\\", "\\\"", "\u00e4\n\/"]`,
+		strings.Repeat(" ", 62) + `"\"" "\\" 1`,
+		strings.Repeat(" ", 61) + `"\\\"\\" 1`,
+		strings.Repeat(`"\\\""`, 100),
+		// invalid input has to be detected in both modes
+		`"unterminated`,
+		"truex",
+		"nulll",
+		"1x",
+		"1\"a\"",
+		"tru",
+		`{"a":"b"}/**/`,
+		"🤣",
+		string([]byte{0x0C}),
+		"'",
+		`"\x"`,
+		`"\u12"`,
+		`"\u12g4"`,
+		`"\"`,
+	}
+	for _, in := range input {
+		name := in
+		if len(name) > 32 {
+			name = name[:32]
+		}
+		t.Run(name, func(t *testing.T) {
+			data := []byte(in)
+			want, wantErr := lexAll(&lexer{data: data})
+			got, gotErr := lexAll(&lexer{data: data, idx: newStructuralIndex(len(data))})
+			assert.Equal(t, wantErr != nil, gotErr != nil, "%v %v", wantErr, gotErr)
+			if wantErr == nil {
+				assert.Equal(t, want, got)
+			}
+		})
+	}
+}
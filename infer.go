@@ -0,0 +1,315 @@
+package libjson
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"net/mail"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xnacly/libjson/internal/format"
+)
+
+// maximum amount of distinct strings at a location inferred as an enum
+const enumLimit = 8
+
+// string formats detected by InferSchema, in order of precedence
+const (
+	formatDateTime uint8 = 1 << iota
+	formatUUID
+	formatEmail
+)
+
+var formatNames = []string{"date-time", "uuid", "email"}
+
+// shape is the union of all values observed at a location of the sample
+// documents
+type shape struct {
+	// amount of values observed
+	count                                           int
+	nulls, bools, numbers, strings, arrays, objects int
+	// amount of numbers without a fractional part
+	integers int
+	// amount of numbers whose literal has neither a fraction nor an exponent,
+	// see intLiteral
+	intLiterals int
+	// distinct strings, nil once more than enumLimit were observed
+	values map[string]struct{}
+	// bitset of the formats all strings conform to
+	formats    uint8
+	properties map[string]*shape
+	// union of the elements of all arrays
+	items *shape
+}
+
+// infer merges the tree of values v into s, n holds the literals of its
+// numbers if recorded
+func (s *shape) infer(v any, n *posNode) {
+	s.count++
+	switch v := v.(type) {
+	case nil:
+		s.nulls++
+	case bool:
+		s.bools++
+	case float64:
+		s.numbers++
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			s.integers++
+		}
+		if intLiteral(v, n.literal()) {
+			s.intLiterals++
+		}
+	case string:
+		if s.strings == 0 {
+			s.values = map[string]struct{}{}
+			s.formats = formatDateTime | formatUUID | formatEmail
+		}
+		s.strings++
+		if s.values != nil {
+			s.values[v] = struct{}{}
+			if len(s.values) > enumLimit {
+				s.values = nil
+			}
+		}
+		s.formats &= stringFormats(v, s.formats)
+	case []any:
+		s.arrays++
+		if s.items == nil {
+			s.items = &shape{}
+		}
+		for i, e := range v {
+			s.items.infer(e, n.child(i))
+		}
+	case map[string]any:
+		s.objects++
+		if s.properties == nil {
+			s.properties = make(map[string]*shape, len(v))
+		}
+		for k, e := range v {
+			p, ok := s.properties[k]
+			if !ok {
+				p = &shape{}
+				s.properties[k] = p
+			}
+			p.infer(e, n.child(k))
+		}
+	}
+}
+
+// intLiteral reports whether the number v with the literal lit is an int64,
+// that is lit has neither a fraction nor an exponent. Without a literal, v
+// must not have a fractional part.
+func intLiteral(v float64, lit string) bool {
+	if math.IsNaN(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+		return false
+	}
+	if lit == "" {
+		return v == math.Trunc(v)
+	}
+	lit = strings.TrimLeft(lit, "+-")
+	if strings.HasPrefix(lit, "0x") || strings.HasPrefix(lit, "0X") {
+		return true
+	}
+	return !strings.ContainsAny(lit, ".eE")
+}
+
+// stringFormats returns the formats of candidates s conforms to
+func stringFormats(s string, candidates uint8) uint8 {
+	r := uint8(0)
+	if candidates&formatDateTime != 0 {
+		if _, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			r |= formatDateTime
+		}
+	}
+	if candidates&formatUUID != 0 && format.UUID.MatchString(s) {
+		r |= formatUUID
+	}
+	if candidates&formatEmail != 0 {
+		if a, err := mail.ParseAddress(s); err == nil && a.Address == s {
+			r |= formatEmail
+		}
+	}
+	return r
+}
+
+// types returns the JSON Schema types of the observed values
+func (s *shape) types() []string {
+	var r []string
+	for _, t := range []struct {
+		name  string
+		count int
+	}{
+		{"object", s.objects},
+		{"array", s.arrays},
+		{"string", s.strings},
+		{"integer", s.integers},
+		{"number", s.numbers - s.integers},
+		{"boolean", s.bools},
+		{"null", s.nulls},
+	} {
+		if t.count > 0 {
+			r = append(r, t.name)
+		}
+	}
+	// integers are numbers, thus only list number if both were observed
+	if s.integers > 0 && s.numbers > s.integers {
+		r = slices.DeleteFunc(r, func(t string) bool { return t == "integer" })
+	}
+	return r
+}
+
+// enum returns the sorted distinct strings, if the strings repeat, have a
+// low cardinality and no format
+func (s *shape) enum() []string {
+	if s.values == nil || s.strings == len(s.values) || s.strings != s.count-s.nulls || s.format() != "" {
+		return nil
+	}
+	return slices.Sorted(maps.Keys(s.values))
+}
+
+// format returns the name of the format all observed strings conform to
+func (s *shape) format() string {
+	if s.strings == 0 {
+		return ""
+	}
+	for i, name := range formatNames {
+		if s.formats&(1<<i) != 0 {
+			return name
+		}
+	}
+	return ""
+}
+
+// schema returns s as a JSON Schema
+func (s *shape) schema() map[string]any {
+	m := map[string]any{}
+	if s.count == 0 {
+		return m
+	}
+	switch types := s.types(); len(types) {
+	case 1:
+		m["type"] = types[0]
+	default:
+		a := make([]any, len(types))
+		for i, t := range types {
+			a[i] = t
+		}
+		m["type"] = a
+	}
+	if enum := s.enum(); enum != nil {
+		a := make([]any, 0, len(enum)+1)
+		for _, e := range enum {
+			a = append(a, e)
+		}
+		if s.nulls > 0 {
+			a = append(a, nil)
+		}
+		m["enum"] = a
+	}
+	if f := s.format(); f != "" {
+		m["format"] = f
+	}
+	if s.objects > 0 {
+		props := make(map[string]any, len(s.properties))
+		var required []any
+		for _, k := range slices.Sorted(maps.Keys(s.properties)) {
+			p := s.properties[k]
+			props[k] = p.schema()
+			if p.count == s.objects {
+				required = append(required, k)
+			}
+		}
+		m["properties"] = props
+		if required != nil {
+			m["required"] = required
+		}
+	}
+	if s.items != nil && s.items.count > 0 {
+		m["items"] = s.items.schema()
+	}
+	return m
+}
+
+// summarize appends a row of path, types and details per location to rows,
+// see Summarize
+func (s *shape) summarize(rows [][3]string, path string, optional bool) [][3]string {
+	var details []string
+	if optional {
+		details = append(details, "optional")
+	}
+	if f := s.format(); f != "" {
+		details = append(details, "format "+f)
+	}
+	if enum := s.enum(); enum != nil {
+		for i, e := range enum {
+			enum[i] = strconv.Quote(e)
+		}
+		details = append(details, "enum "+strings.Join(enum, ", "))
+	}
+	row := [3]string{path, strings.Join(s.types(), " | ")}
+	if len(details) > 0 {
+		row[2] = "(" + strings.Join(details, ", ") + ")"
+	}
+	rows = append(rows, row)
+
+	if path == "." {
+		path = ""
+	}
+	for _, k := range slices.Sorted(maps.Keys(s.properties)) {
+		p := s.properties[k]
+		rows = p.summarize(rows, path+"."+k, p.count < s.objects)
+	}
+	if s.items != nil && s.items.count > 0 {
+		rows = s.items.summarize(rows, path+"[]", false)
+	}
+	return rows
+}
+
+func inferShape(docs []*JSON) *shape {
+	s := &shape{}
+	for _, doc := range docs {
+		s.infer(doc.obj, doc.lits)
+	}
+	return s
+}
+
+// InferSchema infers a JSON Schema (draft 2020-12) describing all docs. The
+// types observed at each location are unioned, object members missing in
+// some of the objects are not required, strings repeating at most 8
+// distinct values are inferred as an enum and strings all conforming to
+// date-time, uuid or email are annotated with the format. The elements of
+// all arrays at a location are described by a single items schema. Numbers
+// without a fractional part are inferred as integers.
+func InferSchema(docs ...*JSON) JSON {
+	m := inferShape(docs).schema()
+	m["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	return JSON{obj: m}
+}
+
+// Summarize infers the structure of docs like InferSchema and formats it as
+// a compact summary, a line per location consisting of its path, the union
+// of its types and its details, such as:
+//
+//	.        object
+//	.id      integer
+//	.status  string  (enum "active", "deleted")
+//	.tags    array   (optional)
+//	.tags[]  string
+func Summarize(docs ...*JSON) string {
+	rows := inferShape(docs).summarize(nil, ".", false)
+	widths := [2]int{}
+	for _, r := range rows {
+		widths[0] = max(widths[0], len(r[0]))
+		widths[1] = max(widths[1], len(r[1]))
+	}
+	b := strings.Builder{}
+	for _, r := range rows {
+		line := fmt.Sprintf("%-*s  %-*s  %s", widths[0], r[0], widths[1], r[1], r[2])
+		b.WriteString(strings.TrimRight(line, " "))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
@@ -0,0 +1,101 @@
+package libjson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func inferSamples(t *testing.T, samples ...string) []*JSON {
+	docs := make([]*JSON, len(samples))
+	for i, s := range samples {
+		j, err := New([]byte(s))
+		assert.NoError(t, err)
+		docs[i] = &j
+	}
+	return docs
+}
+
+func TestInferSchema(t *testing.T) {
+	docs := inferSamples(t,
+		`{"id": 1, "status": "active", "tags": ["a"], "at": "2024-01-01T00:00:00Z", "user": {"mail": "a@example.com"}}`,
+		`{"id": 2, "status": "active", "at": "2024-01-02T00:00:00Z", "user": null, "score": 1.5}`,
+		`{"id": 3, "status": "deleted", "tags": [], "at": "2024-01-03T00:00:00Z", "score": 2, "ref": "123e4567-e89b-12d3-a456-426614174000"}`,
+	)
+	s := InferSchema(docs...)
+	out, err := s.Append(nil)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"required": ["at", "id", "status"],
+		"properties": {
+			"at": {"type": "string", "format": "date-time"},
+			"id": {"type": "integer"},
+			"ref": {"type": "string", "format": "uuid"},
+			"score": {"type": "number"},
+			"status": {"type": "string", "enum": ["active", "deleted"]},
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"user": {
+				"type": ["object", "null"],
+				"required": ["mail"],
+				"properties": {"mail": {"type": "string", "format": "email"}}
+			}
+		}
+	}`, string(out))
+}
+
+func TestInferSchemaValues(t *testing.T) {
+	input := []struct {
+		samples []string
+		want    string
+	}{
+		{nil, `{}`},
+		{[]string{`null`}, `{"type": "null"}`},
+		{[]string{`1`, `"a"`, `true`}, `{"type": ["string", "integer", "boolean"]}`},
+		{[]string{`[]`}, `{"type": "array"}`},
+		{[]string{`[1, 2.5, null]`}, `{"type": "array", "items": {"type": ["number", "null"]}}`},
+		{[]string{`["a", "b"]`}, `{"type": "array", "items": {"type": "string"}}`},
+		{[]string{`["a", "a", null]`}, `{"type": "array", "items": {"type": ["string", "null"], "enum": ["a", null]}}`},
+		{[]string{`["a", "a", 1]`}, `{"type": "array", "items": {"type": ["string", "integer"]}}`},
+		{[]string{`["x@example.com", "no mail"]`}, `{"type": "array", "items": {"type": "string"}}`},
+		{[]string{`[{"a": 1}, {"b": 1}]`}, `{"type": "array", "items": {"type": "object", "properties": {"a": {"type": "integer"}, "b": {"type": "integer"}}}}`},
+	}
+	for _, i := range input {
+		t.Run(i.want, func(t *testing.T) {
+			s := InferSchema(inferSamples(t, i.samples...)...)
+			m := s.obj.(map[string]any)
+			delete(m, "$schema")
+			out, err := s.Append(nil)
+			assert.NoError(t, err)
+			assert.JSONEq(t, i.want, string(out))
+		})
+	}
+}
+
+func TestInferSchemaEnumLimit(t *testing.T) {
+	samples := make([]string, 0, 20)
+	for i := range 10 {
+		v := `"` + string(rune('a'+i)) + `"`
+		samples = append(samples, v, v)
+	}
+	s := InferSchema(inferSamples(t, samples...)...)
+	_, ok := s.obj.(map[string]any)["enum"]
+	assert.False(t, ok)
+}
+
+func TestSummarize(t *testing.T) {
+	docs := inferSamples(t,
+		`{"id": 1, "status": "active", "tags": ["a"], "user": {"mail": "a@example.com"}}`,
+		`{"id": 2, "status": "active", "user": null}`,
+		`{"id": 3, "status": "deleted", "tags": []}`,
+	)
+	assert.Equal(t, `.           object
+.id         integer
+.status     string         (enum "active", "deleted")
+.tags       array          (optional)
+.tags[]     string
+.user       object | null  (optional)
+.user.mail  string         (format email)
+`, Summarize(docs...))
+}
@@ -0,0 +1,8 @@
+// Package format holds the string formats of JSON Schema shared by the schema
+// inference of libjson and the validation of the schema package
+package format
+
+import "regexp"
+
+// UUID matches the uuid format (rfc4122)
+var UUID = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
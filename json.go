@@ -4,24 +4,52 @@ import (
 	"io"
 )
 
-func NewReader(r io.Reader) (JSON, error) {
-	data, err := io.ReadAll(r)
-	if err != nil {
-		return JSON{}, err
+// NewReader parses the json read from r, without reading r into memory at
+// once, see lexer.fill. Since the buffer used for reading is reused, all
+// strings of the result are copied and never alias it.
+func NewReader(r io.Reader, opts ...Option) (JSON, error) {
+	p := parser{config: newConfig(opts)}
+	p.l = lexer{r: r, data: make([]byte, 0, bufSize), dialect: p.dialect, nonFinite: p.allowNonFinite}
+	if p.intern {
+		p.keys = make(map[string]string, 64)
 	}
-	p := parser{l: lexer{data: data}}
-	obj, err := p.parse(data)
+	if p.positions || p.keepLiterals {
+		p.track()
+	}
+	obj, err := p.parse()
 	if err != nil {
 		return JSON{}, err
 	}
-	return JSON{obj}, nil
+	return JSON{obj: obj, pos: p.recorded(), lits: p.literals()}, nil
 }
 
-func New(data []byte) (JSON, error) {
-	p := parser{l: lexer{data: data}}
-	obj, err := p.parse(data)
+// New parses data, errors in the input are reported as *SyntaxError.
+//
+// To avoid copying, the strings and object keys of the result alias data, thus
+// data must not be modified as long as the result or any string taken from it
+// is in use, otherwise these strings change as well. Pass CopyStrings if data
+// is reused, for instance a pooled buffer or a bytes.Buffer.
+func New(data []byte, opts ...Option) (JSON, error) {
+	p := parser{config: newConfig(opts)}
+	p.l = lexer{data: data, dialect: p.dialect, nonFinite: p.allowNonFinite}
+	if p.dialect == Strict {
+		p.l.idx = newStructuralIndex(len(data))
+	}
+	if p.intern || p.copy {
+		p.keys = make(map[string]string, 64)
+	}
+	if p.positions || p.keepLiterals {
+		p.track()
+	}
+	var obj any
+	var err error
+	if p.workers > 1 && p.arena == nil && p.l.idx != nil && p.tracker == nil {
+		obj, err = p.parallel()
+	} else {
+		obj, err = p.parse()
+	}
 	if err != nil {
 		return JSON{}, err
 	}
-	return JSON{obj}, nil
+	return JSON{obj: obj, pos: p.recorded(), lits: p.literals()}, nil
 }
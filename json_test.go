@@ -1,9 +1,14 @@
 package libjson
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
 	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -21,6 +26,121 @@ func BenchmarkLibJson(b *testing.B) {
 	b.ReportAllocs()
 }
 
+func BenchmarkLibJsonParallel(b *testing.B) {
+	data := strings.Repeat(`{"key1": "value","array": [],"obj": {},"atomArray": [11201,1e112,true,false,null,"str"]},`, amount)
+	d := []byte("[" + data[:len(data)-1] + "]")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := New(d, Parallel(runtime.NumCPU()))
+		assert.NoError(b, err)
+	}
+	b.ReportAllocs()
+}
+
+func BenchmarkLibJsonArena(b *testing.B) {
+	data := strings.Repeat(`{"key1": "value","array": [],"obj": {},"atomArray": [11201,1e112,true,false,null,"str"]},`, amount)
+	d := []byte("[" + data[:len(data)-1] + "]")
+	a := NewArena()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		a.Reset()
+		_, err := New(d, UseArena(a))
+		assert.NoError(b, err)
+	}
+	b.ReportAllocs()
+}
+
+func BenchmarkLibJsonReader(b *testing.B) {
+	data := strings.Repeat(`{"key1": "value","array": [],"obj": {},"atomArray": [11201,1e112,true,false,null,"str"]},`, amount)
+	d := []byte("[" + data[:len(data)-1] + "]")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := NewReader(bytes.NewReader(d))
+		assert.NoError(b, err)
+	}
+	b.ReportAllocs()
+}
+
+func BenchmarkLibJsonLazy(b *testing.B) {
+	data := strings.Repeat(`{"key1": "value","array": [],"obj": {},"atomArray": [11201,1e112,true,false,null,"str"]},`, amount)
+	d := []byte(`{"meta": {"id": 1}, "data": [` + data[:len(data)-1] + "]}")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := NewLazy(d).Get(".meta.id")
+		assert.NoError(b, err)
+	}
+	b.ReportAllocs()
+}
+
+// genData mirrors test/gen.py
+func genData(size int) []byte {
+	line := "\t{\n        \"key1\": \"value\",\n        \"array\": [],\n        \"obj\": {},\n        \"atomArray\": [11201,1e112,true,false,null,\"str\"]\n    }"
+	amount := size * 1_000_000 / len(line)
+	lines := make([]string, amount)
+	for i := range lines {
+		lines[i] = line
+	}
+	return []byte("[\n" + strings.Join(lines, ",\n") + "\n]")
+}
+
+func BenchmarkStructuralIndex(b *testing.B) {
+	for _, size := range []int{1, 5, 10} {
+		d := genData(size)
+		b.Run(fmt.Sprintf("%dMB/lexer", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				p := parser{l: lexer{data: d}}
+				_, err := p.parse()
+				assert.NoError(b, err)
+			}
+			b.ReportAllocs()
+		})
+		b.Run(fmt.Sprintf("%dMB/index", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				p := parser{l: lexer{data: d, idx: newStructuralIndex(len(d))}}
+				_, err := p.parse()
+				assert.NoError(b, err)
+			}
+			b.ReportAllocs()
+		})
+		// lexing only, without the allocations of the parser
+		b.Run(fmt.Sprintf("%dMB/lexer-tokens", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				l := lexer{data: d}
+				for {
+					tok, err := l.next()
+					assert.NoError(b, err)
+					if tok.Type == t_eof {
+						break
+					}
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("%dMB/index-tokens", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				l := lexer{data: d, idx: newStructuralIndex(len(d))}
+				for {
+					tok, err := l.next()
+					assert.NoError(b, err)
+					if tok.Type == t_eof {
+						break
+					}
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkLibJsonReaderInternKeys(b *testing.B) {
+	data := strings.Repeat(`{"key1": "value","array": [],"obj": {},"atomArray": [11201,1e112,true,false,null,"str"]},`, amount)
+	d := []byte("[" + data[:len(data)-1] + "]")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := NewReader(bytes.NewReader(d), InternKeys())
+		assert.NoError(b, err)
+	}
+	b.ReportAllocs()
+}
+
 func BenchmarkEncodingJson(b *testing.B) {
 	data := strings.Repeat(`{"key1": "value","array": [],"obj": {},"atomArray": [11201,1e112,true,false,null,"str"]},`, amount)
 	d := []byte("[" + data[:len(data)-1] + "]")
@@ -37,3 +157,31 @@ func BenchmarkEncodingJson(b *testing.B) {
 	}
 	b.ReportAllocs()
 }
+
+func TestNewReader(t *testing.T) {
+	data := strings.Repeat(`{"key1": "value","array": [],"obj": {},"atomArray": [11201,1e112,true,false,null,"str"]},`, 5_000)
+	d := []byte("[" + data[:len(data)-1] + "]")
+	want, err := New(d)
+	assert.NoError(t, err)
+
+	got, err := NewReader(bytes.NewReader(d))
+	assert.NoError(t, err)
+	assert.EqualValues(t, want, got)
+
+	// forces every token to be split across buffer refills
+	got, err = NewReader(iotest.OneByteReader(bytes.NewReader(d)))
+	assert.NoError(t, err)
+	assert.EqualValues(t, want, got)
+}
+
+func TestNewReaderFail(t *testing.T) {
+	readErr := errors.New("read failed")
+	_, err := NewReader(iotest.ErrReader(readErr))
+	assert.ErrorIs(t, err, readErr)
+
+	_, err = NewReader(iotest.TimeoutReader(strings.NewReader(`{"key": "value"}`)))
+	assert.ErrorIs(t, err, iotest.ErrTimeout)
+
+	_, err = NewReader(strings.NewReader(`{"key": "val`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,231 @@
+package libjson
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+// Lazy is a document that is only indexed and parsed as far as required by
+// the paths accessed via Get. Subtrees not on an accessed path are skipped by
+// matching their brackets, without converting their strings or numbers, thus
+// errors in these subtrees are not detected.
+type Lazy struct {
+	data []byte
+	root *lazyNode
+	config
+}
+
+// lazyNode is a value in Lazy.data, its members or elements are indexed on
+// first access
+type lazyNode struct {
+	// byte span of the value, may include surrounding whitespace
+	start int
+	end   int
+	// type of the first token of the value
+	typ     t_json
+	indexed bool
+	keys    map[string]*lazyNode
+	elems   []*lazyNode
+	parsed  bool
+	val     any
+}
+
+// NewLazy creates a Lazy document for data, the resulting values alias data
+// unless CopyStrings is passed, see New. Values are parsed from data on their
+// first access, thus data must not be modified while the Lazy is in use, even
+// with CopyStrings.
+func NewLazy(data []byte, opts ...Option) *Lazy {
+	return &Lazy{data: data, root: &lazyNode{start: 0, end: len(data), typ: -1}, config: newConfig(opts)}
+}
+
+// LazyGet is Get for Lazy documents
+func LazyGet[T any](l *Lazy, path string) (T, error) {
+	val, err := l.Get(path)
+	if err != nil {
+		var e T
+		return e, err
+	}
+	if castVal, ok := val.(T); !ok {
+		var e T
+		return e, fmt.Errorf("Expected value of type %T, got type %T", e, val)
+	} else {
+		return castVal, nil
+	}
+}
+
+// Get returns the value at path, only parsing the containers on the way to
+// and the value itself
+func (l *Lazy) Get(path string) (any, error) {
+	keys, err := splitPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", errors.ErrUnsupported, path)
+	}
+	node := l.root
+	for _, k := range keys {
+		if err := l.index(node); err != nil {
+			return nil, err
+		}
+		switch node.typ {
+		case t_left_braket:
+			i, ok := k.(int)
+			if !ok {
+				return nil, fmt.Errorf("Can not use %T::%v to index into array", k, k)
+			}
+			if i >= len(node.elems) {
+				return nil, fmt.Errorf("Index %d out of range for array of length %d", i, len(node.elems))
+			}
+			node = node.elems[i]
+		case t_left_curly:
+			key, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("Can not use %T::%v to index into object", k, k)
+			}
+			child, ok := node.keys[key]
+			if !ok {
+				return nil, fmt.Errorf("Key %q not found in object", key)
+			}
+			node = child
+		default:
+			return nil, fmt.Errorf("Can not index into %s", tokennames[node.typ])
+		}
+	}
+	return l.materialize(node)
+}
+
+// materialize parses the value of node, caching the result
+func (l *Lazy) materialize(n *lazyNode) (any, error) {
+	if n.parsed {
+		return n.val, nil
+	}
+	in := l.data[n.start:n.end]
+	p := parser{l: lexer{data: in, nonFinite: l.allowNonFinite}, config: l.config}
+	val, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	n.val = val
+	n.parsed = true
+	return val, nil
+}
+
+// index records the spans of the members or elements of n
+func (l *Lazy) index(n *lazyNode) error {
+	if n.indexed {
+		return nil
+	}
+	lex := lexer{data: l.data[:n.end], pos: n.start, nonFinite: l.allowNonFinite}
+	t, err := lex.next()
+	if err != nil {
+		return err
+	}
+	n.typ = t.Type
+	switch t.Type {
+	case t_left_curly:
+		n.keys = make(map[string]*lazyNode, 4)
+	case t_left_braket:
+		n.elems = make([]*lazyNode, 0, 8)
+	default:
+		n.indexed = true
+		return nil
+	}
+
+	t, err = lex.next()
+	if err != nil {
+		return err
+	}
+	for t.Type != t_eof {
+		if (n.typ == t_left_curly && t.Type == t_right_curly) || (n.typ == t_left_braket && t.Type == t_right_braket) {
+			n.indexed = true
+			return nil
+		}
+		if len(n.keys) > 0 || len(n.elems) > 0 {
+			if t.Type != t_comma {
+				return fmt.Errorf("Unexpected %q at this position, expected %q", tokennames[t.Type], tokennames[t_comma])
+			}
+			if t, err = lex.next(); err != nil {
+				return err
+			}
+		}
+
+		var key string
+		if n.typ == t_left_curly {
+			if t.Type != t_string {
+				return fmt.Errorf("Unexpected %q at this position, expected %q", tokennames[t.Type], tokennames[t_string])
+			}
+			in := l.data[t.Start:t.End]
+			if t.Escaped {
+				key = unescape(in)
+			} else if l.copy {
+				key = string(in)
+			} else {
+				key = *(*string)(unsafe.Pointer(&in))
+			}
+			if t, err = lex.next(); err != nil {
+				return err
+			}
+			if t.Type != t_colon {
+				return fmt.Errorf("Unexpected %q at this position, expected %q", tokennames[t.Type], tokennames[t_colon])
+			}
+			if t, err = lex.next(); err != nil {
+				return err
+			}
+		}
+
+		child := &lazyNode{start: lex.start, typ: t.Type}
+		if err := skipValue(&lex, t); err != nil {
+			return err
+		}
+		child.end = lex.pos
+
+		if n.typ == t_left_curly {
+			n.keys[key] = child
+		} else {
+			n.elems = append(n.elems, child)
+		}
+
+		if t, err = lex.next(); err != nil {
+			return err
+		}
+	}
+	return errors.New("Unexpected end of JSON input")
+}
+
+// skipValue advances l to the end of the value starting with t, containers
+// are skipped by matching their brackets on the raw bytes, without lexing
+// their contents
+func skipValue(l *lexer, t token) error {
+	switch t.Type {
+	case t_left_curly, t_left_braket:
+	case t_string, t_number, t_true, t_false, t_null:
+		return nil
+	default:
+		return fmt.Errorf("Unexpected %q at this position, expected any of: string, number, true, false or null", tokennames[t.Type])
+	}
+	depth := 1
+	for l.pos < len(l.data) {
+		switch l.data[l.pos] {
+		case '"':
+			// as in lexer.next, the byte following a backslash does not end
+			// the string
+			for l.pos++; l.pos < len(l.data) && l.data[l.pos] != '"'; l.pos++ {
+				if l.data[l.pos] == '\\' {
+					l.pos++
+				}
+			}
+			if l.pos >= len(l.data) {
+				return errors.New("Unterminated string detected")
+			}
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				l.pos++
+				return nil
+			}
+		}
+		l.pos++
+	}
+	return errors.New("Unexpected end of JSON input")
+}
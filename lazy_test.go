@@ -0,0 +1,81 @@
+package libjson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLazy(t *testing.T) {
+	input := `{
+		"meta": {"id": "abc", "count": 2},
+		"data": [{"name": "a"}, {"name": "b", "tags": ["x", "y"]}],
+		"broken": [1, 2, tru],
+		"escaped": {"b": "x\"}]", "c": ["\\"]},
+		"empty": {}
+	}`
+	input2 := `{"a": [1, {"b": null}], "c": 5}`
+	l := NewLazy([]byte(input))
+	tests := []struct {
+		path     string
+		expected any
+	}{
+		{".meta.id", "abc"},
+		{".meta.count", 2.0},
+		{".data.1.name", "b"},
+		{".data.1.tags.1", "y"},
+		{".data.0", map[string]any{"name": "a"}},
+		{".empty", map[string]any{}},
+		{".escaped.b", `x"}]`},
+		{".escaped.c.0", `\`},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			val, err := l.Get(test.path)
+			assert.NoError(t, err)
+			assert.EqualValues(t, test.expected, val)
+		})
+	}
+
+	// the invalid array is only detected once accessed
+	_, err := l.Get(".broken")
+	assert.Error(t, err)
+
+	id, err := LazyGet[string](l, ".meta.id")
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", id)
+	_, err = LazyGet[float64](l, ".meta.id")
+	assert.Error(t, err)
+
+	root, err := NewLazy([]byte(input2)).Get(".")
+	assert.NoError(t, err)
+	want, err := New([]byte(input2))
+	assert.NoError(t, err)
+	assert.EqualValues(t, want.obj, root)
+}
+
+func TestLazyFail(t *testing.T) {
+	input := []struct {
+		inp  string
+		path string
+	}{
+		{`{"a": 1}`, ""},
+		{`{"a": 1}`, ".a.b"},
+		{`{"a": [1]}`, ".a.b"},
+		{`{"a": [1]}`, ".a.1"},
+		{`{"meta": {"id": 1}}`, ".meta.missing"},
+		{`{}`, ".missing"},
+		{`{"a": {"b": 1}}`, ".a.0"},
+		{`{"a" 1}`, ".a"},
+		{`{"a": 1 "b": 2}`, ".b"},
+		{`{"a": [1, 2`, ".b"},
+		{`[1, 2`, ".1"},
+		{`{"a": {"b": 1`, ".a.b"},
+	}
+	for _, i := range input {
+		t.Run(i.inp+i.path, func(t *testing.T) {
+			_, err := NewLazy([]byte(i.inp)).Get(i.path)
+			assert.Error(t, err)
+		})
+	}
+}
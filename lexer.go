@@ -1,29 +1,188 @@
 package libjson
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
 )
 
+// initial size of the buffer used for reading from an io.Reader, see
+// lexer.fill
+const bufSize = 64 * 1024
+
 type lexer struct {
 	data []byte
 	pos  int
+	// offset of the most recently lexed token in data
+	start int
+
+	// only set for streaming, if so data is a window into r which is refilled
+	// once exhausted, see lexer.fill
+	r io.Reader
+	// offset of data[0] in the stream
+	base int64
+	// first error returned by r
+	err error
+
+	// treat the RFC 7464 record separator (0x1E) as the end of the input,
+	// see SeqReader
+	rs bool
+	// the last t_eof was produced by a record separator
+	record bool
+
+	// if set, next jumps from structural character to structural character
+	// instead of scanning whitespace and strings byte by byte, see index.go
+	idx *structuralIndex
+
+	// accepted syntax, the structural index is only used for Strict
+	dialect Dialect
+	// accept NaN, Infinity and -Infinity as numbers, see AllowNonFinite
+	nonFinite bool
+	// if set, the offsets of the line breaks in discarded input are
+	// appended, see RecordPositions
+	lines *[]int64
 }
 
 func (l *lexer) advance() (byte, error) {
 	if l.pos >= len(l.data) {
-		return 0, io.EOF
+		if l.r == nil || !l.fill() {
+			return 0, l.eof()
+		}
 	}
 	cc := l.data[l.pos]
 	l.pos++
 	return cc, nil
 }
 
+// escapes validates the escape sequences of the string t, these are resolved
+// by the parser, see unescape
+func (l *lexer) escapes(t token) error {
+	s := l.data[t.Start:t.End]
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			continue
+		}
+		i++
+		switch s[i] {
+		case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+		case 'u':
+			if i+4 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2]) || !isHex(s[i+3]) || !isHex(s[i+4]) {
+				return errors.New("Invalid escape '\\u', expected 4 hexadecimal digits")
+			}
+			i += 4
+		default:
+			return fmt.Errorf("Invalid escape '\\%c' in string", s[i])
+		}
+	}
+	return nil
+}
+
+// eof returns the error of the underlying reader if it failed for any other
+// reason than io.EOF
+func (l *lexer) eof() error {
+	if l.err != nil && l.err != io.EOF {
+		return l.err
+	}
+	return io.EOF
+}
+
+// fill discards all bytes before the current token and reads more input into
+// data, growing it only if the current token does not fit, thus the memory
+// usage scales with the largest token instead of the size of the input.
+// Reports whether new bytes are available.
+func (l *lexer) fill() bool {
+	if l.err != nil {
+		return false
+	}
+	if l.start > 0 {
+		if l.lines != nil {
+			*l.lines = appendLines(*l.lines, l.data[:l.start], l.base)
+		}
+		n := copy(l.data, l.data[l.start:])
+		l.data = l.data[:n]
+		l.pos -= l.start
+		l.base += int64(l.start)
+		l.start = 0
+	}
+	if len(l.data) == cap(l.data) {
+		size := 2 * cap(l.data)
+		if size == 0 {
+			size = bufSize
+		}
+		grown := make([]byte, len(l.data), size)
+		copy(grown, l.data)
+		l.data = grown
+	}
+	for {
+		n, err := l.r.Read(l.data[len(l.data):cap(l.data)])
+		l.data = l.data[:len(l.data)+n]
+		if err != nil {
+			l.err = err
+			return n > 0
+		}
+		if n > 0 {
+			return true
+		}
+	}
+}
+
+// ensure reports whether n bytes starting at l.pos are available
+func (l *lexer) ensure(n int) bool {
+	for l.pos+n > len(l.data) {
+		if l.r == nil || !l.fill() {
+			return false
+		}
+	}
+	return true
+}
+
 func (l *lexer) next() (token, error) {
+	if l.idx != nil {
+		pos, ok := l.idx.next(l.data)
+		if !ok {
+			l.start, l.pos = len(l.data), len(l.data)
+			return empty, nil
+		}
+		l.start, l.pos = pos, pos+1
+		switch l.data[pos] {
+		case '{':
+			return token{Type: t_left_curly}, nil
+		case '}':
+			return token{Type: t_right_curly}, nil
+		case '[':
+			return token{Type: t_left_braket}, nil
+		case ']':
+			return token{Type: t_right_braket}, nil
+		case ',':
+			return token{Type: t_comma}, nil
+		case ':':
+			return token{Type: t_colon}, nil
+		case '"':
+			// the closing quote is always the next offset, since the
+			// structural index does not contain offsets inside of strings
+			end, ok := l.idx.next(l.data)
+			if !ok {
+				return empty, errors.New("Unterminated string detected")
+			}
+			l.pos = end + 1
+			t := token{Type: t_string, Start: pos + 1, End: end}
+			if bytes.IndexByte(l.data[t.Start:t.End], '\\') >= 0 {
+				t.Escaped = true
+				if err := l.escapes(t); err != nil {
+					return empty, err
+				}
+			}
+			return t, nil
+		}
+		// scalars are lexed byte by byte below
+		l.pos = pos
+	}
+
+	l.start = l.pos
 	cc, err := l.advance()
 	if err != nil {
-		return empty, nil
+		return empty, l.readErr(err)
 	}
 
 	tt := t_eof
@@ -31,7 +190,19 @@ func (l *lexer) next() (token, error) {
 	for cc == ' ' || cc == '\n' || cc == '\t' || cc == '\r' {
 		cc, err = l.advance()
 		if err != nil {
-			return empty, nil
+			return empty, l.readErr(err)
+		}
+	}
+	if l.dialect != Strict {
+		cc, err = l.trivia(cc)
+		if err != nil {
+			return empty, l.readErr(err)
+		}
+	}
+	l.start = l.pos - 1
+	if l.dialect == JSON5 {
+		if t, ok, err := l.json5(cc); ok {
+			return t, err
 		}
 	}
 
@@ -49,21 +220,35 @@ func (l *lexer) next() (token, error) {
 	case ':':
 		tt = t_colon
 	case '"':
-		start := l.pos
-		end := start
+		escaped := false
 		for {
 			cc, err = l.advance()
+			if err == nil && cc == '\\' {
+				escaped = true
+				// the escaped byte does not end the string, even if it is a
+				// quote
+				if cc, err = l.advance(); err == nil {
+					continue
+				}
+			}
 			if cc == '"' {
-				end = l.pos - 1
 				break
 			} else if err != nil {
+				if err = l.readErr(err); err != nil {
+					return empty, err
+				}
 				return empty, errors.New("Unterminated string detected")
 			}
 		}
-		t := token{Type: t_string, Start: start, End: end}
+		t := token{Type: t_string, Start: l.start + 1, End: l.pos - 1, Escaped: escaped}
+		if escaped {
+			if err := l.escapes(t); err != nil {
+				return empty, err
+			}
+		}
 		return t, nil
 	case 't': // this should always be the 'true' atom and is therefore optimised here
-		if l.pos+3 > len(l.data) {
+		if !l.ensure(3) {
 			return empty, errors.New("Failed to read the expected 'true' atom")
 		}
 		if !(l.data[l.pos] == 'r' && l.data[l.pos+1] == 'u' && l.data[l.pos+2] == 'e') {
@@ -72,7 +257,7 @@ func (l *lexer) next() (token, error) {
 		l.pos += 3
 		tt = t_true
 	case 'f': // this should always be the 'false' atom and is therefore optimised here
-		if l.pos+4 > len(l.data) {
+		if !l.ensure(4) {
 			return empty, errors.New("Failed to read the expected 'false' atom")
 		}
 		if !(l.data[l.pos] == 'a' && l.data[l.pos+1] == 'l' && l.data[l.pos+2] == 's' && l.data[l.pos+3] == 'e') {
@@ -81,7 +266,7 @@ func (l *lexer) next() (token, error) {
 		l.pos += 4
 		tt = t_false
 	case 'n': // this should always be the 'null' atom and is therefore optimised here
-		if l.pos+3 > len(l.data) {
+		if !l.ensure(3) {
 			return empty, errors.New("Failed to read the expected 'null' atom")
 		}
 		if !(l.data[l.pos] == 'u' && l.data[l.pos+1] == 'l' && l.data[l.pos+2] == 'l') {
@@ -90,17 +275,34 @@ func (l *lexer) next() (token, error) {
 		l.pos += 3
 		tt = t_null
 	default:
+		if l.nonFinite && (cc == 'N' || cc == 'I' || cc == '-') {
+			ok, err := l.nonFiniteNumber(cc)
+			if err != nil {
+				return empty, err
+			}
+			if ok {
+				if l.idx != nil && !l.delimited() {
+					return empty, fmt.Errorf("Unexpected character %q at this position.", l.data[l.pos])
+				}
+				return token{Type: t_number, Start: l.start, End: l.pos}, nil
+			}
+		}
 		if cc == '-' || (cc >= '0' && cc <= '9') {
-			start := l.pos - 1
 			cc, err = l.advance()
 			if err != nil {
-				return token{Type: t_number, Start: start, End: l.pos}, nil
+				if err = l.readErr(err); err != nil {
+					return empty, err
+				}
+				return token{Type: t_number, Start: l.start, End: l.pos}, nil
 			}
 
 			for {
 				if (cc >= '0' && cc <= '9') || cc == '-' || cc == '+' || cc == '.' || cc == 'e' || cc == 'E' {
 					cc, err = l.advance()
 					if err != nil {
+						if err = l.readErr(err); err != nil {
+							return empty, err
+						}
 						break
 					}
 				} else {
@@ -112,15 +314,60 @@ func (l *lexer) next() (token, error) {
 				}
 			}
 
-			return token{Type: t_number, Start: start, End: l.pos}, nil
+			if l.idx != nil && !l.delimited() {
+				return empty, fmt.Errorf("Unexpected character %q at this position.", l.data[l.pos])
+			}
+			return token{Type: t_number, Start: l.start, End: l.pos}, nil
+		} else if cc == 0x1E && l.rs {
+			l.record = true
+			return empty, nil
 		} else {
 			return empty, fmt.Errorf("Unexpected character %q at this position.", cc)
 		}
 	}
 
+	if l.idx != nil && tt <= t_null && !l.delimited() {
+		return empty, fmt.Errorf("Unexpected character %q at this position.", l.data[l.pos])
+	}
 	return token{Type: tt}, nil
 }
 
+// nonFiniteNumber lexes NaN, Infinity and -Infinity, whose first byte is
+// already consumed, reporting false if a '-' is not followed by Infinity
+func (l *lexer) nonFiniteNumber(cc byte) (bool, error) {
+	lit := "Infinity"
+	switch cc {
+	case 'N':
+		lit = "NaN"
+	case '-':
+		if c, ok := l.peek(); !ok || c != 'I' {
+			return false, nil
+		}
+		l.pos++
+	}
+	rest := len(lit) - 1
+	if !l.ensure(rest) || string(l.data[l.pos:l.pos+rest]) != lit[1:] {
+		return false, fmt.Errorf("Failed to read the expected '%s' atom", lit)
+	}
+	l.pos += rest
+	return true, nil
+}
+
+// delimited reports whether the scalar ending at l.pos is followed by a
+// delimiter or the end of the input, otherwise the structural index skipped
+// the bytes following it
+func (l *lexer) delimited() bool {
+	return l.pos >= len(l.data) || delimiter(l.data[l.pos])
+}
+
+// readErr drops io.EOF, since the end of the input is signaled via t_eof
+func (l *lexer) readErr(err error) error {
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
 // lex is only intended for tests, use lexer.next() for production code
 func (l *lexer) lex(r io.Reader) ([]token, error) {
 	var err error
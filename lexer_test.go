@@ -148,3 +148,32 @@ func TestLexerFail(t *testing.T) {
 		})
 	}
 }
+
+func TestLexerStreaming(t *testing.T) {
+	json := `{"key": "a string spanning more than one buffer", "num": -129.1928e-19028, "atoms": [` + strings.Repeat("true, false, null, ", 32) + `1]}`
+	in := []byte(json)
+	l := lexer{data: in}
+	var want []string
+	for {
+		tok, err := l.next()
+		assert.NoError(t, err)
+		if tok.Type == t_eof {
+			break
+		}
+		want = append(want, tokennames[tok.Type]+string(in[tok.Start:tok.End]))
+	}
+
+	l = lexer{r: strings.NewReader(json), data: make([]byte, 0, 4)}
+	var got []string
+	for {
+		tok, err := l.next()
+		assert.NoError(t, err)
+		if tok.Type == t_eof {
+			break
+		}
+		got = append(got, tokennames[tok.Type]+string(l.data[tok.Start:tok.End]))
+	}
+	assert.Equal(t, want, got)
+	// the buffer only grows to fit the largest token
+	assert.Less(t, cap(l.data), len(json)/2)
+}
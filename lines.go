@@ -0,0 +1,87 @@
+package libjson
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// LineError is returned by (*LinesReader).Next for a line not holding a valid
+// json value
+type LineError struct {
+	// 1 based line number
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// LinesReader reads newline delimited json (NDJSON / JSON Lines), one value
+// per line, empty lines are skipped
+type LinesReader struct {
+	r    *bufio.Reader
+	line int
+	opts []Option
+}
+
+// NewLinesReader reads the lines of r, parsing each via New with opts
+func NewLinesReader(r io.Reader, opts ...Option) *LinesReader {
+	return &LinesReader{r: bufio.NewReaderSize(r, bufSize), opts: opts}
+}
+
+// Next returns the value of the next line or io.EOF once all lines are read.
+// A line with invalid json results in a *LineError, calling Next again skips
+// said line and continues with the following one.
+func (lr *LinesReader) Next() (JSON, error) {
+	for {
+		line, err := lr.r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return JSON{}, err
+		}
+		if len(line) == 0 && err == io.EOF {
+			return JSON{}, io.EOF
+		}
+		lr.line++
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		// line is freshly allocated by ReadBytes, thus the result can alias it
+		j, perr := New(line, lr.opts...)
+		if perr != nil {
+			return JSON{}, &LineError{Line: lr.line, Err: perr}
+		}
+		return j, nil
+	}
+}
+
+// LinesWriter writes newline delimited json (NDJSON / JSON Lines)
+type LinesWriter struct {
+	w   io.Writer
+	buf []byte
+	config
+}
+
+// NewLinesWriter writes values encoded via (*JSON).Append with opts to w,
+// thus unlike MarshalJSON '<', '>' and '&' are not escaped for html and
+// non-finite numbers are encoded as configured via EncodeNonFinite
+func NewLinesWriter(w io.Writer, opts ...Option) *LinesWriter {
+	return &LinesWriter{w: w, config: newConfig(opts)}
+}
+
+// Write writes j followed by a newline
+func (lw *LinesWriter) Write(j *JSON) error {
+	b, err := appendValue(lw.buf[:0], j.obj, &lw.config)
+	if err != nil {
+		return err
+	}
+	lw.buf = append(b, '\n')
+	_, err = lw.w.Write(lw.buf)
+	return err
+}
@@ -0,0 +1,85 @@
+package libjson
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinesReader(t *testing.T) {
+	input := "{\"a\": 1}\r\n\n[1,2]\n  \n\"str\"\nnull"
+	lr := NewLinesReader(strings.NewReader(input))
+	got := []any{}
+	for {
+		j, err := lr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		got = append(got, j.Root().Interface())
+	}
+	assert.Equal(t, []any{map[string]any{"a": 1.0}, []any{1.0, 2.0}, "str", nil}, got)
+}
+
+func TestLinesReaderInvalid(t *testing.T) {
+	input := "1\n{\"a\": }\n\n[1,\n2"
+	lr := NewLinesReader(strings.NewReader(input))
+	got := []any{}
+	lines := []int{}
+	for {
+		j, err := lr.Next()
+		if err == io.EOF {
+			break
+		}
+		var lerr *LineError
+		if errors.As(err, &lerr) {
+			lines = append(lines, lerr.Line)
+			continue
+		}
+		assert.NoError(t, err)
+		got = append(got, j.Root().Interface())
+	}
+	assert.Equal(t, []any{1.0, 2.0}, got)
+	assert.Equal(t, []int{2, 4}, lines)
+}
+
+func TestLinesWriter(t *testing.T) {
+	b := &bytes.Buffer{}
+	lw := NewLinesWriter(b)
+	for _, in := range []string{`{"a": [1, 2]}`, `"str"`, "null", `"<a & b>"`} {
+		j, err := New([]byte(in))
+		assert.NoError(t, err)
+		assert.NoError(t, lw.Write(&j))
+	}
+	// unlike MarshalJSON, strings are not escaped for html
+	assert.Equal(t, "{\"a\":[1,2]}\n\"str\"\nnull\n\"<a & b>\"\n", b.String())
+
+	lr := NewLinesReader(b)
+	for range 4 {
+		_, err := lr.Next()
+		assert.NoError(t, err)
+	}
+	_, err := lr.Next()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestLinesNonFinite(t *testing.T) {
+	b := &bytes.Buffer{}
+	lw := NewLinesWriter(b, EncodeNonFinite(NonFiniteLiteral))
+	in, err := New([]byte(`{"loss": NaN, "max": Infinity}`), AllowNonFinite())
+	assert.NoError(t, err)
+	assert.NoError(t, lw.Write(&in))
+	assert.Equal(t, "{\"loss\":NaN,\"max\":Infinity}\n", b.String())
+
+	assert.Error(t, NewLinesWriter(io.Discard).Write(&in))
+
+	lr := NewLinesReader(b, AllowNonFinite())
+	out, err := lr.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, math.Inf(1), out.obj.(map[string]any)["max"])
+}
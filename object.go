@@ -4,13 +4,21 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"iter"
 	"strconv"
+	"strings"
 )
 
 type JSON struct {
 	obj any
+	// only set if parsed with RecordPositions
+	pos *positions
+	// only set if parsed with KeepNumberLiterals, mirrors obj
+	lits *posNode
 }
 
+// Get returns the value at path as T. Missing object keys and out of range
+// indexes are reported as errors.
 func Get[T any](obj *JSON, path string) (T, error) {
 	val, err := obj.get(path)
 	if err != nil {
@@ -34,38 +42,39 @@ func indexByKey(data any, key any) (any, error) {
 	case float64:
 		return nil, errors.New("Can not index into number")
 	case []any:
-		if len(v) == 0 {
-			return nil, nil
-		}
-		if k, ok := key.(int); !ok {
+		k, ok := key.(int)
+		if !ok {
 			return nil, fmt.Errorf("Can not use %T::%v to index into %T::%v", key, key, data, data)
-		} else {
-			return v[k], nil
 		}
-	case map[string]any:
-		if len(v) == 0 {
-			return nil, nil
+		if k < 0 || k >= len(v) {
+			return nil, fmt.Errorf("Index %d out of range for array of length %d", k, len(v))
 		}
-		if k, ok := key.(string); !ok {
+		return v[k], nil
+	case map[string]any:
+		k, ok := key.(string)
+		if !ok {
 			return nil, fmt.Errorf("Can not use %T::%v to index into %T::%v", key, key, data, data)
-		} else {
-			return v[k], nil
 		}
+		val, ok := v[k]
+		if !ok {
+			return nil, fmt.Errorf("Key %q not found in object", k)
+		}
+		return val, nil
 	default:
 		return nil, fmt.Errorf("Unsupported %T, can not index", data)
 	}
 }
 
-func parsePath(path string) (func(any) (any, error), error) {
+// splitPath splits path into its keys, numeric keys are converted to int for
+// indexing into arrays
+func splitPath(path string) ([]any, error) {
 	if len(path) == 0 {
 		return nil, errors.New("Unexpected index syntax, top level element is available via '.'")
 	}
 
 	// fast paths for '.' path / parent access
 	if len(path) == 1 && path[0] == '.' {
-		return func(a any) (any, error) {
-			return a, nil
-		}, nil
+		return nil, nil
 	}
 
 	// skip first . because we handled that above
@@ -75,23 +84,39 @@ func parsePath(path string) (func(any) (any, error), error) {
 	lastIndex := 0
 	for i, b := range path {
 		if b == '.' {
-			keys = append(keys, path[lastIndex:i])
+			keys = append(keys, pathKey(path[lastIndex:i]))
 			lastIndex = i + 1
 		} else if i+1 == len(path) {
-			keys = append(keys, path[lastIndex:i+1])
+			keys = append(keys, pathKey(path[lastIndex:i+1]))
 		}
 	}
+	return keys, nil
+}
+
+func pathKey(key string) any {
+	if len(key) > 0 && key[0] >= '0' && key[0] <= '9' {
+		if k, err := strconv.ParseInt(key, 10, 32); err == nil {
+			return int(k)
+		}
+	}
+	return key
+}
+
+func parsePath(path string) (func(any) (any, error), error) {
+	keys, err := splitPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) == 0 {
+		return func(a any) (any, error) {
+			return a, nil
+		}, nil
+	}
 
 	return func(a any) (any, error) {
 		val := a
 		for _, k := range keys {
-			key := k.(string)
-			if key[0] >= '0' && key[0] <= '9' {
-				if k1, err := strconv.ParseInt(key, 10, 32); err == nil {
-					k = int(k1)
-				}
-			}
-
 			if v, err := indexByKey(val, k); err != nil {
 				return nil, err
 			} else {
@@ -113,3 +138,107 @@ func (j *JSON) get(path string) (any, error) {
 func (j *JSON) MarshalJSON() ([]byte, error) {
 	return json.Marshal(j.obj)
 }
+
+// Elements iterates over the elements of the array at path. If path does not
+// resolve to an array, a single pair holding an invalid Value with the
+// corresponding error is yielded.
+func (j *JSON) Elements(path string) iter.Seq2[int, Value] {
+	return func(yield func(int, Value) bool) {
+		v := j.Root().Path(path)
+		a, ok := v.v.([]any)
+		if !ok {
+			if v.err == nil {
+				v = Value{err: fmt.Errorf("Can not iterate over elements of %s at %q", v.Kind(), path)}
+			}
+			yield(0, v)
+			return
+		}
+		for i, e := range a {
+			if !yield(i, Value{v: e}) {
+				return
+			}
+		}
+	}
+}
+
+// Members iterates over the members of the object at path in an unspecified
+// order. If path does not resolve to an object, a single pair holding an
+// invalid Value with the corresponding error is yielded.
+func (j *JSON) Members(path string) iter.Seq2[string, Value] {
+	return func(yield func(string, Value) bool) {
+		v := j.Root().Path(path)
+		m, ok := v.v.(map[string]any)
+		if !ok {
+			if v.err == nil {
+				v = Value{err: fmt.Errorf("Can not iterate over members of %s at %q", v.Kind(), path)}
+			}
+			yield("", v)
+			return
+		}
+		for k, e := range m {
+			if !yield(k, Value{v: e}) {
+				return
+			}
+		}
+	}
+}
+
+// Path is the location of a value inside a document, consisting of object
+// keys (string) and array indexes (int)
+type Path []any
+
+// String formats p in the syntax accepted by Get, for instance
+// ".hello.world.0"
+func (p Path) String() string {
+	if len(p) == 0 {
+		return "."
+	}
+	b := strings.Builder{}
+	for _, k := range p {
+		b.WriteByte('.')
+		switch k := k.(type) {
+		case string:
+			b.WriteString(k)
+		case int:
+			b.WriteString(strconv.Itoa(k))
+		}
+	}
+	return b.String()
+}
+
+// Walk traverses the document depth first, starting with the top level
+// value at the empty Path. Calling Value.SkipChildren on a yielded value
+// prevents Walk from descending into it. The yielded Path is reused between
+// iterations and must be copied (via slices.Clone) if it is retained.
+func (j *JSON) Walk() iter.Seq2[Path, Value] {
+	return func(yield func(Path, Value) bool) {
+		skip := false
+		path := make(Path, 0, 8)
+		walk(j.obj, path, &skip, yield)
+	}
+}
+
+func walk(node any, path Path, skip *bool, yield func(Path, Value) bool) bool {
+	if !yield(path, Value{v: node, skip: skip}) {
+		return false
+	}
+	if *skip {
+		*skip = false
+		return true
+	}
+	switch v := node.(type) {
+	case []any:
+		for i, e := range v {
+			if !walk(e, append(path, i), skip, yield) {
+				return false
+			}
+		}
+	case map[string]any:
+		for k, e := range v {
+			if !walk(e, append(path, k), skip, yield) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -44,6 +44,29 @@ func TestObjectReadme(t *testing.T) {
 	assert.EqualValues(t, "hi", val)
 }
 
+func TestObjectGetFail(t *testing.T) {
+	input := []struct {
+		inp  string
+		path string
+	}{
+		{`{"a": [1]}`, ".a.1"},
+		{`[[1, 2]]`, ".0.5"},
+		{`{"a": 1}`, ".a.b"},
+		// missing keys and indexes into empty containers are errors as well
+		{`{"a": 1}`, ".b"},
+		{`{"a": {}}`, ".a.b"},
+		{`{"a": []}`, ".a.0"},
+	}
+	for _, i := range input {
+		t.Run(i.inp+i.path, func(t *testing.T) {
+			obj, err := New([]byte(i.inp))
+			assert.NoError(t, err)
+			_, err = Get[any](&obj, i.path)
+			assert.Error(t, err)
+		})
+	}
+}
+
 func TestStandardFail(t *testing.T) {
 	input := []string{
 		`{"a":"b"}/**/`,
@@ -56,8 +79,68 @@ func TestStandardFail(t *testing.T) {
 		t.Run(i, func(t *testing.T) {
 			in := []byte(i)
 			p := parser{l: lexer{data: in}}
-			_, err := p.parse(in)
+			_, err := p.parse()
 			assert.Error(t, err)
 		})
 	}
 }
+
+func TestObjectElements(t *testing.T) {
+	obj, err := New([]byte(`{"arr": [1, "two", true]}`))
+	assert.NoError(t, err)
+	got := []any{}
+	for i, v := range obj.Elements(".arr") {
+		assert.NoError(t, v.Err())
+		assert.Equal(t, len(got), i)
+		got = append(got, v.Interface())
+	}
+	assert.EqualValues(t, []any{1.0, "two", true}, got)
+
+	for _, v := range obj.Elements(".") {
+		assert.Error(t, v.Err())
+	}
+}
+
+func TestObjectMembers(t *testing.T) {
+	obj, err := New([]byte(`{"users": {"a": 1, "b": 2}}`))
+	assert.NoError(t, err)
+	got := map[string]any{}
+	for k, v := range obj.Members(".users") {
+		assert.NoError(t, v.Err())
+		got[k] = v.Interface()
+	}
+	assert.EqualValues(t, map[string]any{"a": 1.0, "b": 2.0}, got)
+
+	for _, v := range obj.Members(".users.a") {
+		assert.Error(t, v.Err())
+	}
+}
+
+func TestObjectWalk(t *testing.T) {
+	obj, err := New([]byte(`{"a": [1, {"b": null}], "skipped": {"c": 1}}`))
+	assert.NoError(t, err)
+	got := map[string]Kind{}
+	for p, v := range obj.Walk() {
+		got[p.String()] = v.Kind()
+		if p.String() == ".skipped" {
+			v.SkipChildren()
+		}
+	}
+	assert.EqualValues(t, map[string]Kind{
+		".":        KindObject,
+		".a":       KindArray,
+		".a.0":     KindNumber,
+		".a.1":     KindObject,
+		".a.1.b":   KindNull,
+		".skipped": KindObject,
+	}, got)
+
+	count := 0
+	for range obj.Walk() {
+		count++
+		if count == 2 {
+			break
+		}
+	}
+	assert.Equal(t, 2, count)
+}
@@ -0,0 +1,109 @@
+package libjson
+
+// Option configures the parser, see New and NewReader
+type Option func(*config)
+
+type config struct {
+	// amount of goroutines used for parsing the elements of a top level
+	// array, see Parallel
+	workers int
+	// see UseArena
+	arena *Arena
+	// see InternKeys
+	intern bool
+	// see CopyStrings
+	copy bool
+	// see DisallowUnknownFields
+	disallowUnknown bool
+	// see UseDialect
+	dialect Dialect
+	// see AllowNonFinite
+	allowNonFinite bool
+	// see EncodeNonFinite
+	nonFinite NonFinite
+	// see RecordPositions
+	positions bool
+	// see KeepNumberLiterals
+	keepLiterals bool
+	// see CompareNumbersByLiteral
+	literalNumbers bool
+	// see Canonical
+	canonical bool
+}
+
+func newConfig(opts []Option) config {
+	c := config{}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// Parallel splits a top level array into chunks at element boundaries and
+// parses these chunks on the given amount of goroutines, joining the results
+// in order. Has no effect for any other top level value, for NewReader and
+// if combined with UseArena or UseDialect.
+func Parallel(workers int) Option {
+	return func(c *config) {
+		c.workers = workers
+	}
+}
+
+// UseArena allocates the arrays, numbers and strings of the parsed tree from
+// a, see Arena
+func UseArena(a *Arena) Option {
+	return func(c *config) {
+		c.arena = a
+	}
+}
+
+// InternKeys makes all occurrences of an object key share a single string,
+// instead of each being its own string. Interned keys are copied and do not
+// alias the input, combined with NewReader, which has to copy all strings,
+// this saves a copy for every repeated key of records style data.
+func InternKeys() Option {
+	return func(c *config) {
+		c.intern = true
+	}
+}
+
+// CopyStrings copies all strings and object keys out of the input, instead
+// of aliasing it, thus the input passed to New may be modified or reused
+// once parsing returned. NewReader always copies.
+func CopyStrings() Option {
+	return func(c *config) {
+		c.copy = true
+	}
+}
+
+// AllowNonFinite accepts the literals NaN, Infinity and -Infinity as emitted
+// by Python's json module and JavaScript, parsing them to the matching
+// float64. JSON5 accepts these regardless.
+func AllowNonFinite() Option {
+	return func(c *config) {
+		c.allowNonFinite = true
+	}
+}
+
+// NonFinite is the policy for encoding NaN and the infinities, which have no
+// json representation, see EncodeNonFinite
+type NonFinite uint8
+
+const (
+	// NonFiniteError rejects non-finite numbers, the default
+	NonFiniteError NonFinite = iota
+	// NonFiniteNull encodes non-finite numbers as null, as JavaScript's
+	// JSON.stringify does
+	NonFiniteNull
+	// NonFiniteLiteral encodes non-finite numbers as NaN, Infinity and
+	// -Infinity, as Python's json module does, see AllowNonFinite
+	NonFiniteLiteral
+)
+
+// EncodeNonFinite sets the policy of (*JSON).Append, LinesWriter and
+// SeqWriter for non-finite numbers
+func EncodeNonFinite(n NonFinite) Option {
+	return func(c *config) {
+		c.nonFinite = n
+	}
+}
@@ -0,0 +1,150 @@
+package libjson
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// minimal size of a chunk, splitting smaller inputs is not worth the overhead
+// of the goroutines
+const minChunk = 64 * 1024
+
+// span is a range of bytes in the input
+type span struct {
+	start int
+	end   int
+}
+
+// parallel parses a top level array by splitting it at its top level commas
+// into chunks, which are parsed concurrently. Falls back to parse for any
+// other input or if the array can not be split, the sequential parser then
+// reports the error at the correct position.
+func (p *parser) parallel() (any, error) {
+	chunks := p.split()
+	if len(chunks) < 2 {
+		return p.parse()
+	}
+
+	results := make([][]any, len(chunks))
+	errs := make([]error, len(chunks))
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range min(p.workers, len(chunks)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= len(chunks) {
+					return
+				}
+				results[i], errs[i] = p.elements(chunks[i])
+			}
+		}()
+	}
+	wg.Wait()
+
+	total := 0
+	for i, err := range errs {
+		// the error of the first chunk is the first error in the input
+		if err != nil {
+			return nil, err
+		}
+		total += len(results[i])
+	}
+	a := make([]any, 0, total)
+	for _, r := range results {
+		a = append(a, r...)
+	}
+	return a, nil
+}
+
+// split uses the structural index to find top level commas of a top level
+// array, cutting the array into chunks of roughly equal size. Returns nil if
+// the input is not a top level array or its brackets do not match.
+func (p *parser) split() []span {
+	data := p.l.data
+	idx := newStructuralIndex(len(data))
+	pos, ok := idx.next(data)
+	if !ok || data[pos] != '[' {
+		return nil
+	}
+
+	target := max(len(data)/(p.workers*4), minChunk)
+	chunks := make([]span, 0, p.workers*4)
+	start := pos + 1
+	depth := 1
+	for {
+		pos, ok = idx.next(data)
+		if !ok {
+			return nil
+		}
+		switch data[pos] {
+		case '"':
+			// skip the closing quote
+			if _, ok = idx.next(data); !ok {
+				return nil
+			}
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				// only whitespace may follow the array
+				if _, ok := idx.next(data); ok || data[pos] != ']' {
+					return nil
+				}
+				return append(chunks, span{start, pos})
+			}
+		case ',':
+			if depth == 1 && pos-start >= target {
+				chunks = append(chunks, span{start, pos})
+				start = pos + 1
+			}
+		}
+	}
+}
+
+// elements parses the comma separated values of s, the offsets of errors
+// are relative to the whole input
+func (p *parser) elements(s span) ([]any, error) {
+	idx := newStructuralIndex(s.end - s.start)
+	idx.off = s.start
+	c := parser{l: lexer{data: p.l.data[:s.end], pos: s.start, idx: idx}, config: p.config}
+	if c.intern || c.copy {
+		// every chunk interns on its own, sharing a table would require
+		// locking
+		c.keys = make(map[string]string, 64)
+	}
+	if err := c.advance(); err != nil {
+		return nil, c.syntaxError(err)
+	}
+
+	a := make([]any, 0, 8)
+	for {
+		if c.cur_tok.Type == t_eof {
+			// the chunk ends right before a comma or the closing bracket
+			tt := t_comma
+			if p.l.data[s.end] == ']' {
+				tt = t_right_braket
+			}
+			return nil, c.syntaxError(fmt.Errorf("Unexpected %q at this position, expected any of: string, number, true, false or null", tokennames[tt]))
+		}
+		val, err := c.expression()
+		if err != nil {
+			return nil, c.syntaxError(err)
+		}
+		a = append(a, val)
+
+		if c.cur_tok.Type == t_eof {
+			return a, nil
+		}
+		if c.cur_tok.Type != t_comma {
+			return nil, c.syntaxError(fmt.Errorf("Unexpected %q at this position, expected %q", tokennames[c.cur_tok.Type], tokennames[t_comma]))
+		}
+		if err := c.advance(); err != nil {
+			return nil, c.syntaxError(err)
+		}
+	}
+}
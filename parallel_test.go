@@ -0,0 +1,56 @@
+package libjson
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParallel(t *testing.T) {
+	input := [][]byte{
+		genData(1),
+		[]byte("[]"),
+		[]byte("[1, 2, 3]"),
+		[]byte(`{"key": [1, 2, 3]}`),
+		[]byte("12"),
+	}
+	for _, in := range input {
+		want, err := New(in)
+		assert.NoError(t, err)
+		got, err := New(in, Parallel(4))
+		assert.NoError(t, err)
+		assert.EqualValues(t, want, got)
+	}
+}
+
+func TestParallelFail(t *testing.T) {
+	data := genData(1)
+	input := [][]byte{
+		// invalid element in the middle of the array
+		bytes.Replace(data, []byte("null,"), []byte("nul, "), 5000),
+		// missing element
+		bytes.Replace(data, []byte("},\n"), []byte("},,"), 6000),
+		// unbalanced brackets, detected by the chunk parser
+		bytes.Replace(data, []byte(`"obj": {}`), []byte(`"obj": {]`), 7000),
+		// trailing comma
+		append(data[:len(data)-2], []byte(",]")...),
+		// trailing data
+		append(data, []byte("{}")...),
+		// unterminated array
+		data[:len(data)-1],
+	}
+	for _, in := range input {
+		_, want := New(in)
+		_, got := New(in, Parallel(4))
+		assert.Error(t, want)
+		assert.Error(t, got)
+
+		var wantErr, gotErr *SyntaxError
+		assert.True(t, errors.As(want, &wantErr))
+		assert.True(t, errors.As(got, &gotErr))
+		assert.Equal(t, wantErr.Offset, gotErr.Offset)
+		assert.Equal(t, want.Error(), got.Error())
+	}
+}
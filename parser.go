@@ -2,6 +2,7 @@ package libjson
 
 import (
 	"fmt"
+	"io"
 	"strconv"
 	"unsafe"
 )
@@ -9,10 +10,46 @@ import (
 type parser struct {
 	l       lexer
 	cur_tok token
-	input   []byte
+	config
+	// elements of the arrays currently parsed, only used with an arena
+	stack []any
+	// already copied object keys, see parser.key
+	keys map[string]string
+	// only set if positions are recorded, see RecordPositions
+	tracker *tracker
+}
+
+// maximum amount of keys stored in parser.keys
+const maxKeys = 4096
+
+// SyntaxError is returned for invalid input, Offset is the byte offset of the
+// token the error was detected at
+type SyntaxError struct {
+	Offset int64
+	Err    error
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s (at offset %d)", e.Err, e.Offset)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// syntaxError attaches the offset of the current token to err, errors of the
+// underlying reader are returned as is
+func (p *parser) syntaxError(err error) error {
+	if p.l.err != nil && p.l.err != io.EOF {
+		return err
+	}
+	return &SyntaxError{Offset: p.l.base + int64(p.l.start), Err: err}
 }
 
 func (p *parser) advance() error {
+	if p.tracker != nil {
+		p.tracker.last = p.l.base + int64(p.l.pos)
+	}
 	t, err := p.l.next()
 	p.cur_tok = t
 	if p.cur_tok.Type == t_eof && err != nil {
@@ -23,26 +60,70 @@ func (p *parser) advance() error {
 
 // parses toks into a valid json representation, thus the return type can be
 // either map[string]any, []any, string, nil, false, true or a number
-func (p *parser) parse(input []byte) (any, error) {
-	p.input = input
+func (p *parser) parse() (any, error) {
 	err := p.advance()
 	if err != nil {
-		return nil, err
+		return nil, p.syntaxError(err)
 	}
 	if val, err := p.expression(); err != nil {
-		return nil, err
+		return nil, p.syntaxError(err)
 	} else {
 		if p.cur_tok.Type != t_eof {
-			return nil, fmt.Errorf("Unexpected non-whitespace character(s) (%s) after JSON data", tokennames[p.cur_tok.Type])
+			return nil, p.syntaxError(fmt.Errorf("Unexpected non-whitespace character(s) (%s) after JSON data", tokennames[p.cur_tok.Type]))
 		}
 		return val, nil
 	}
 }
 
+// str returns the content of the current token as a string, aliasing the
+// input unless strings are copied, see CopyStrings
+func (p *parser) str() string {
+	in := p.l.data[p.cur_tok.Start:p.cur_tok.End]
+	if p.cur_tok.Escaped {
+		return unescape(in)
+	}
+	if p.l.r != nil || p.copy {
+		// the lexer reuses its buffer on refill, thus we have to copy
+		return string(in)
+	}
+	return *(*string)(unsafe.Pointer(&in))
+}
+
+// key returns the current token as an object key, if keys are copied or
+// interned, an already known key is reused instead of allocating a new string
+func (p *parser) key() string {
+	if p.keys == nil || p.cur_tok.Escaped || (p.l.r == nil && !p.intern && !p.copy) {
+		return p.str()
+	}
+	in := p.l.data[p.cur_tok.Start:p.cur_tok.End]
+	if k, ok := p.keys[string(in)]; ok {
+		return k
+	}
+	k := string(in)
+	if len(p.keys) < maxKeys {
+		p.keys[k] = k
+	}
+	return k
+}
+
 func (p *parser) expression() (any, error) {
+	if p.tracker != nil {
+		return p.tracked()
+	}
+	return p.value()
+}
+
+func (p *parser) value() (any, error) {
 	if p.cur_tok.Type == t_left_curly {
 		return p.object()
 	} else if p.cur_tok.Type == t_left_braket {
+		if p.arena != nil {
+			a, err := p.array()
+			if err != nil {
+				return nil, err
+			}
+			return p.arena.slice(a), nil
+		}
 		return p.array()
 	} else {
 		return p.atom()
@@ -77,13 +158,19 @@ func (p *parser) object() (map[string]any, error) {
 			if err != nil {
 				return nil, err
 			}
+			if p.cur_tok.Type == t_right_curly && p.l.dialect != Strict {
+				break
+			}
 		}
 
-		if p.cur_tok.Type != t_string {
+		if p.cur_tok.Type != t_string && !p.identifier() {
 			return nil, fmt.Errorf("Unexpected %q at this position, expected %q", tokennames[p.cur_tok.Type], tokennames[t_string])
 		}
-		in := p.input[p.cur_tok.Start:p.cur_tok.End]
-		key := *(*string)(unsafe.Pointer(&in))
+		key := p.key()
+		if t := p.tracker; t != nil {
+			t.key, t.name = true, key
+			t.keyStart, t.keyEnd = p.l.base+int64(p.l.start), p.l.base+int64(p.l.pos)
+		}
 		err := p.advance()
 		if err != nil {
 			return nil, err
@@ -136,10 +223,16 @@ func (p *parser) array() ([]any, error) {
 		return []any{}, p.advance()
 	}
 
-	a := make([]any, 0, 8)
+	var a []any
+	if p.arena == nil {
+		a = make([]any, 0, 8)
+	}
+	// with an arena, the elements are collected on the stack and copied into
+	// the arena once their amount is known
+	base := len(p.stack)
 
 	for p.cur_tok.Type != t_eof && p.cur_tok.Type != t_right_braket {
-		if len(a) > 0 {
+		if len(a) > 0 || len(p.stack) > base {
 			if p.cur_tok.Type != t_comma {
 				return nil, fmt.Errorf("Unexpected %q at this position, expected %q", tokennames[p.cur_tok.Type], tokennames[t_comma])
 			}
@@ -147,18 +240,31 @@ func (p *parser) array() ([]any, error) {
 			if err != nil {
 				return nil, err
 			}
+			if p.cur_tok.Type == t_right_braket && p.l.dialect != Strict {
+				break
+			}
 		}
 		node, err := p.expression()
 		if err != nil {
 			return nil, err
 		}
-		a = append(a, node)
+		if p.arena != nil {
+			p.stack = append(p.stack, node)
+		} else {
+			a = append(a, node)
+		}
 	}
 
 	if p.cur_tok.Type != t_right_braket {
 		return nil, fmt.Errorf("Unexpected %q at this position, expected %q", tokennames[p.cur_tok.Type], tokennames[t_right_braket])
 	}
 
+	if p.arena != nil {
+		a = p.arena.array(p.stack[base:])
+		clear(p.stack[base:])
+		p.stack = p.stack[:base]
+	}
+
 	return a, p.advance()
 }
 
@@ -166,16 +272,21 @@ func (p *parser) atom() (any, error) {
 	var r any
 	switch p.cur_tok.Type {
 	case t_string:
-		in := p.input[p.cur_tok.Start:p.cur_tok.End]
-		r = *(*string)(unsafe.Pointer(&in))
+		if p.arena != nil {
+			r = p.arena.string(p.str())
+		} else {
+			r = p.str()
+		}
 	case t_number:
-		in := p.input[p.cur_tok.Start:p.cur_tok.End]
-		raw := *(*string)(unsafe.Pointer(&in))
-		number, err := strconv.ParseFloat(raw, 64)
+		number, err := p.number()
 		if err != nil {
-			return empty, fmt.Errorf("Invalid floating point number %q: %w", raw, err)
+			return empty, err
+		}
+		if p.arena != nil {
+			r = p.arena.float(number)
+		} else {
+			r = number
 		}
-		r = number
 	case t_true:
 		r = true
 	case t_false:
@@ -190,3 +301,20 @@ func (p *parser) atom() (any, error) {
 	}
 	return r, nil
 }
+
+// number parses the current t_number token
+func (p *parser) number() (float64, error) {
+	in := p.l.data[p.cur_tok.Start:p.cur_tok.End]
+	raw := *(*string)(unsafe.Pointer(&in))
+	var number float64
+	var err error
+	if p.l.dialect == JSON5 {
+		number, err = parseNumber5(raw)
+	} else {
+		number, err = strconv.ParseFloat(raw, 64)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("Invalid floating point number %q: %w", raw, err)
+	}
+	return number, nil
+}
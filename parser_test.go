@@ -1,7 +1,13 @@
 package libjson
 
 import (
+	"bytes"
+	"math"
+	"slices"
+	"strings"
 	"testing"
+	"testing/iotest"
+	"unsafe"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -31,7 +37,7 @@ func TestParserAtoms(t *testing.T) {
 		t.Run(in, func(t *testing.T) {
 			in := []byte(in)
 			p := &parser{l: lexer{data: in}}
-			out, err := p.parse(in)
+			out, err := p.parse()
 			assert.NoError(t, err)
 			assert.EqualValues(t, wanted[i], out)
 		})
@@ -55,7 +61,7 @@ func TestParserArray(t *testing.T) {
 		t.Run(in, func(t *testing.T) {
 			in := []byte(in)
 			p := &parser{l: lexer{data: in}}
-			out, err := p.parse(in)
+			out, err := p.parse()
 			assert.NoError(t, err)
 			assert.EqualValues(t, wanted[i], out)
 		})
@@ -83,7 +89,7 @@ func TestParserObject(t *testing.T) {
 		t.Run(in, func(t *testing.T) {
 			in := []byte(in)
 			p := &parser{l: lexer{data: in}}
-			out, err := p.parse(in)
+			out, err := p.parse()
 			assert.NoError(t, err)
 			assert.EqualValues(t, wanted[i], out)
 		})
@@ -111,7 +117,7 @@ func TestParserEdge(t *testing.T) {
 		t.Run(in, func(t *testing.T) {
 			in := []byte(in)
 			p := &parser{l: lexer{data: in}}
-			out, err := p.parse(in)
+			out, err := p.parse()
 			assert.NoError(t, err)
 			assert.EqualValues(t, wanted[i], out)
 		})
@@ -141,14 +147,141 @@ func TestParserFail(t *testing.T) {
 		"1.0e+",
 		"0E",
 		"1eE2",
+		`"\x41"`,
+		`"\u00g4"`,
+		`"\"`,
+		`{"\a": 1}`,
 	}
 	for _, in := range input {
 		t.Run(in, func(t *testing.T) {
 			in := []byte(in)
 			p := &parser{l: lexer{data: in}}
-			out, err := p.parse(in)
+			out, err := p.parse()
 			assert.Error(t, err)
 			assert.Nil(t, out)
 		})
 	}
 }
+
+func TestParserInternKeys(t *testing.T) {
+	input := []byte(`[{"key": 1, "other": 2}, {"key": 3, "other": 4}]`)
+	for _, parse := range []func() (JSON, error){
+		func() (JSON, error) { return New(input, InternKeys()) },
+		func() (JSON, error) { return NewReader(bytes.NewReader(input), InternKeys()) },
+		func() (JSON, error) { return NewParser(InternKeys()).Parse(input) },
+	} {
+		obj, err := parse()
+		assert.NoError(t, err)
+		a := obj.obj.([]any)
+		keys := [2][]string{}
+		for i, o := range a {
+			for k := range o.(map[string]any) {
+				keys[i] = append(keys[i], k)
+			}
+			slices.Sort(keys[i])
+		}
+		assert.Equal(t, []string{"key", "other"}, keys[0])
+		for i := range keys[0] {
+			// both objects share the same backing memory for their keys
+			assert.Equal(t, unsafe.StringData(keys[0][i]), unsafe.StringData(keys[1][i]))
+			// interned keys do not alias the input
+			ptr := uintptr(unsafe.Pointer(unsafe.StringData(keys[0][i])))
+			start := uintptr(unsafe.Pointer(&input[0]))
+			assert.False(t, ptr >= start && ptr < start+uintptr(len(input)))
+		}
+	}
+}
+
+func TestParserCopyStrings(t *testing.T) {
+	const input = `{"key": "value", "list": ["a", "b"]}`
+	parses := []func([]byte) (any, error){
+		func(in []byte) (any, error) { j, err := New(in, CopyStrings()); return j.obj, err },
+		func(in []byte) (any, error) { j, err := NewParser(CopyStrings()).Parse(in); return j.obj, err },
+		func(in []byte) (any, error) { j, err := NewReader(bytes.NewReader(in)); return j.obj, err },
+		func(in []byte) (any, error) { return NewLazy(in, CopyStrings()).Get(".") },
+	}
+	want := map[string]any{"key": "value", "list": []any{"a", "b"}}
+	for _, parse := range parses {
+		in := []byte(input)
+		obj, err := parse(in)
+		assert.NoError(t, err)
+		// simulates reusing the buffer, for instance via a sync.Pool
+		for i := range in {
+			in[i] = 'x'
+		}
+		assert.Equal(t, want, obj)
+	}
+
+	t.Run("alias", func(t *testing.T) {
+		in := []byte(input)
+		obj, err := New(in)
+		assert.NoError(t, err)
+		copy(in[strings.Index(input, "value"):], "xxxxx")
+		assert.Equal(t, "xxxxx", obj.obj.(map[string]any)["key"])
+	})
+}
+
+func TestParserNonFinite(t *testing.T) {
+	in := `{"nan": NaN, "inf": Infinity, "ninf": -Infinity, "arr": [NaN,-Infinity], "n": -1}`
+	check := func(t *testing.T, j JSON) {
+		m := j.obj.(map[string]any)
+		assert.True(t, math.IsNaN(m["nan"].(float64)))
+		assert.Equal(t, math.Inf(1), m["inf"])
+		assert.Equal(t, math.Inf(-1), m["ninf"])
+		assert.True(t, math.IsNaN(m["arr"].([]any)[0].(float64)))
+		assert.Equal(t, math.Inf(-1), m["arr"].([]any)[1])
+		assert.Equal(t, -1.0, m["n"])
+	}
+
+	j, err := New([]byte(in), AllowNonFinite())
+	assert.NoError(t, err)
+	check(t, j)
+	j, err = NewReader(iotest.OneByteReader(strings.NewReader(in)), AllowNonFinite())
+	assert.NoError(t, err)
+	check(t, j)
+	j, err = NewParser(AllowNonFinite()).Parse([]byte(in))
+	assert.NoError(t, err)
+	check(t, j)
+	j, err = New([]byte("// comment\n"+in), AllowNonFinite(), UseDialect(JSONC))
+	assert.NoError(t, err)
+	check(t, j)
+	v, err := NewLazy([]byte(in), AllowNonFinite()).Get(".arr.1")
+	assert.NoError(t, err)
+	assert.Equal(t, math.Inf(-1), v)
+
+	_, err = New([]byte(in))
+	assert.Error(t, err, "non-finite numbers are rejected by default")
+
+	for _, i := range []string{"Nan", "NaNa", "Inf", "-Inf", "-Infinityy", "[Infinity1]", "-", "I"} {
+		t.Run(i, func(t *testing.T) {
+			_, err := New([]byte(i), AllowNonFinite())
+			assert.Error(t, err)
+			_, err = NewReader(strings.NewReader(i), AllowNonFinite())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParserEscapes(t *testing.T) {
+	input := map[string]any{
+		`"a\"b"`:                     `a"b`,
+		`"\\"`:                       `\`,
+		`"\\\""`:                     `\"`,
+		`"\/\b\f\n\r\t"`:             "/\b\f\n\r\t",
+		`"\u00e4\u20AC\ud83d\ude00"`: "ä€😀",
+		`{"a\"b": ["\n"], "\\": 1}`:  map[string]any{`a"b`: []any{"\n"}, `\`: 1.0},
+	}
+	for in, want := range input {
+		t.Run(in, func(t *testing.T) {
+			for _, opts := range [][]Option{nil, {CopyStrings()}, {InternKeys()}} {
+				j, err := New([]byte(in), opts...)
+				assert.NoError(t, err)
+				assert.Equal(t, want, j.obj)
+
+				j, err = NewReader(iotest.OneByteReader(strings.NewReader(in)), opts...)
+				assert.NoError(t, err)
+				assert.Equal(t, want, j.obj)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package libjson
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// RecordPositions makes New, NewReader and Parser record the location of
+// every value and object key in the input, see (*JSON).Position. Disables
+// Parallel.
+func RecordPositions() Option {
+	return func(c *config) {
+		c.positions = true
+	}
+}
+
+// KeepNumberLiterals makes New, NewReader, NewSeqReader and Parser keep the
+// literal text of every number in the input, which float64 does not
+// preserve, for instance 2 and 2.0 are the same float64. The literals are
+// used by GenerateStructs and by Equal with CompareNumbersByLiteral.
+// Disables Parallel.
+func KeepNumberLiterals() Option {
+	return func(c *config) {
+		c.keepLiterals = true
+	}
+}
+
+// Span is the location of a value or an object key in the input
+type Span struct {
+	// byte offsets of the first byte and the byte following the last byte,
+	// including the quotes of strings
+	Start, End int64
+	// 1 based line and column of Start, the column counts bytes
+	Line, Column int
+}
+
+// Position is the location of a value and its key
+type Position struct {
+	Value Span
+	// zero for the top level value and the elements of arrays
+	Key Span
+}
+
+// positions holds the locations of the values of a JSON, see
+// RecordPositions
+type positions struct {
+	root *posNode
+	// offsets of all line breaks in the input
+	lines []int64
+}
+
+// posNode is the location of a value, mirroring the tree of values
+type posNode struct {
+	start, end int64
+	// only set for object members
+	key              bool
+	keyStart, keyEnd int64
+	members          map[string]*posNode
+	elems            []*posNode
+	// text of numbers, only set with KeepNumberLiterals
+	lit string
+}
+
+// Position returns the location of the value at path and of its key in the
+// input, it requires j to be parsed with RecordPositions
+func (j *JSON) Position(path string) (Position, error) {
+	if j.pos == nil {
+		return Position{}, errors.New("Positions were not recorded, parse with RecordPositions")
+	}
+	keys, err := splitPath(path)
+	if err != nil {
+		return Position{}, fmt.Errorf("%w: %q", errors.ErrUnsupported, path)
+	}
+	n := j.pos.root
+	for _, k := range keys {
+		var next *posNode
+		switch k := k.(type) {
+		case string:
+			next = n.members[k]
+		case int:
+			if k < len(n.elems) {
+				next = n.elems[k]
+			} else {
+				// objects with numeric keys
+				next = n.members[strconv.Itoa(k)]
+			}
+		}
+		if next == nil {
+			return Position{}, fmt.Errorf("No value at %q", path)
+		}
+		n = next
+	}
+	p := Position{Value: j.pos.span(n.start, n.end)}
+	if n.key {
+		p.Key = j.pos.span(n.keyStart, n.keyEnd)
+	}
+	return p, nil
+}
+
+func (ps *positions) span(start, end int64) Span {
+	// amount of line breaks preceding start
+	i, _ := slices.BinarySearch(ps.lines, start)
+	column := start + 1
+	if i > 0 {
+		column = start - ps.lines[i-1]
+	}
+	return Span{Start: start, End: end, Line: i + 1, Column: int(column)}
+}
+
+// appendLines appends the offsets of the line breaks in data, which starts
+// at offset base of the input, to lines
+func appendLines(lines []int64, data []byte, base int64) []int64 {
+	off := 0
+	for {
+		i := bytes.IndexByte(data[off:], '\n')
+		if i < 0 {
+			return lines
+		}
+		lines = append(lines, base+int64(off+i))
+		off += i + 1
+	}
+}
+
+// tracker records the positions while parsing
+type tracker struct {
+	positions
+	// container of the value currently parsed
+	cur *posNode
+	// end offset of the last consumed token, see parser.advance
+	last int64
+	// key of the next value, see parser.object
+	key              bool
+	name             string
+	keyStart, keyEnd int64
+	// record the text of numbers, see KeepNumberLiterals
+	literals bool
+}
+
+// track records the positions of the values parsed by p
+func (p *parser) track() {
+	p.tracker = &tracker{literals: p.keepLiterals}
+	if p.positions {
+		p.l.lines = &p.tracker.lines
+	}
+}
+
+// tracked parses the current value like parser.value, recording its
+// position
+func (p *parser) tracked() (any, error) {
+	t := p.tracker
+	n := &posNode{start: p.l.base + int64(p.l.start)}
+	parent := t.cur
+	if parent == nil {
+		t.root = n
+	} else if t.key {
+		n.key, n.keyStart, n.keyEnd = true, t.keyStart, t.keyEnd
+		t.key = false
+		if parent.members == nil {
+			parent.members = make(map[string]*posNode, 4)
+		}
+		parent.members[t.name] = n
+	} else {
+		parent.elems = append(parent.elems, n)
+	}
+	t.cur = n
+	if t.literals && p.cur_tok.Type == t_number {
+		n.lit = string(p.l.data[p.cur_tok.Start:p.cur_tok.End])
+	}
+	v, err := p.value()
+	t.cur = parent
+	n.end = t.last
+	return v, err
+}
+
+// recorded returns the recorded positions once parsing is done
+func (p *parser) recorded() *positions {
+	if p.tracker == nil || !p.positions {
+		return nil
+	}
+	// the line breaks of discarded input are recorded by lexer.fill
+	p.tracker.lines = appendLines(p.tracker.lines, p.l.data, p.l.base)
+	return &p.tracker.positions
+}
+
+// literals returns the recorded number literals once parsing is done
+func (p *parser) literals() *posNode {
+	if p.tracker == nil || !p.keepLiterals {
+		return nil
+	}
+	return p.tracker.root
+}
+
+// child returns the node of the member or element k of n, nil if n is nil
+func (n *posNode) child(k any) *posNode {
+	if n == nil {
+		return nil
+	}
+	switch k := k.(type) {
+	case string:
+		return n.members[k]
+	case int:
+		if k < len(n.elems) {
+			return n.elems[k]
+		}
+	}
+	return nil
+}
+
+// literal returns the text of the number n, the empty string if it was not
+// recorded
+func (n *posNode) literal() string {
+	if n == nil {
+		return ""
+	}
+	return n.lit
+}
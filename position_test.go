@@ -0,0 +1,138 @@
+package libjson
+
+import (
+	"bytes"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPosition(t *testing.T) {
+	in := []byte(`{
+  "name": "libjson",
+  "tags": [1, "two", {"three": 3}],
+  "nested": {"deep": {"er": null}},
+	"10": true
+}`)
+	// Span.Start/End of a value or key spelled out in in, with its line and column
+	type want struct {
+		path      string
+		value     string
+		line, col int
+		key       string
+	}
+	wanted := []want{
+		{".", string(in), 1, 1, ""},
+		{".name", `"libjson"`, 2, 11, `"name"`},
+		{".tags", `[1, "two", {"three": 3}]`, 3, 11, `"tags"`},
+		{".tags.0", `1`, 3, 12, ""},
+		{".tags.1", `"two"`, 3, 15, ""},
+		{".tags.2.three", `3`, 3, 32, `"three"`},
+		{".nested.deep.er", `null`, 4, 29, `"er"`},
+		{".10", `true`, 5, 8, `"10"`},
+	}
+
+	for _, opts := range [][]Option{
+		{RecordPositions()},
+		{RecordPositions(), Parallel(4)},
+		{RecordPositions(), UseDialect(JSONC)},
+	} {
+		j, err := New(in, opts...)
+		assert.NoError(t, err)
+		r, err := NewReader(iotest.OneByteReader(bytes.NewReader(in)), opts...)
+		assert.NoError(t, err)
+		p, err := NewParser(opts...).Parse(in)
+		assert.NoError(t, err)
+		for _, j := range []JSON{j, r, p} {
+			for _, w := range wanted {
+				pos, err := j.Position(w.path)
+				assert.NoError(t, err, w.path)
+				assert.Equal(t, w.value, string(in[pos.Value.Start:pos.Value.End]), w.path)
+				assert.Equal(t, w.line, pos.Value.Line, w.path)
+				assert.Equal(t, w.col, pos.Value.Column, w.path)
+				if w.key == "" {
+					assert.Zero(t, pos.Key, w.path)
+				} else {
+					assert.Equal(t, w.key, string(in[pos.Key.Start:pos.Key.End]), w.path)
+					assert.Equal(t, w.line, pos.Key.Line, w.path)
+				}
+			}
+		}
+	}
+}
+
+func TestPositionReaderRefill(t *testing.T) {
+	// forces lexer.fill to discard the input preceding the value
+	in := bytes.Repeat([]byte(" \n"), bufSize)
+	in = append(in, `{"a": [true]}`...)
+	j, err := NewReader(bytes.NewReader(in), RecordPositions())
+	assert.NoError(t, err)
+	pos, err := j.Position(".a.0")
+	assert.NoError(t, err)
+	assert.Equal(t, Span{Start: int64(len(in) - 6), End: int64(len(in) - 2), Line: bufSize + 1, Column: 8}, pos.Value)
+
+	pr, err := NewParser(RecordPositions()).ParseReader(bytes.NewReader(in))
+	assert.NoError(t, err)
+	pos, err = pr.Position(".a.0")
+	assert.NoError(t, err)
+	assert.Equal(t, bufSize+1, pos.Value.Line)
+}
+
+func TestPositionFail(t *testing.T) {
+	j, err := New([]byte(`{"a": [1]}`))
+	assert.NoError(t, err)
+	_, err = j.Position(".a")
+	assert.Error(t, err, "positions are not recorded by default")
+
+	j, err = New([]byte(`{"a": [1]}`), RecordPositions())
+	assert.NoError(t, err)
+	for _, path := range []string{"", ".b", ".a.1", ".a.0.x", ".a.x"} {
+		_, err = j.Position(path)
+		assert.Error(t, err, path)
+	}
+}
+
+func TestKeepNumberLiterals(t *testing.T) {
+	const in = `{"a": [1.50, -0, 1e3, "s"], "b": {"c": 2}}`
+	parses := map[string]func(opts ...Option) (JSON, error){
+		"New": func(opts ...Option) (JSON, error) { return New([]byte(in), opts...) },
+		"NewReader": func(opts ...Option) (JSON, error) {
+			return NewReader(iotest.OneByteReader(bytes.NewReader([]byte(in))), opts...)
+		},
+		"Parse": func(opts ...Option) (JSON, error) { return NewParser(opts...).Parse([]byte(in)) },
+		"ParseReader": func(opts ...Option) (JSON, error) {
+			return NewParser(opts...).ParseReader(bytes.NewReader([]byte(in)))
+		},
+		"SeqReader": func(opts ...Option) (JSON, error) {
+			return NewSeqReader(bytes.NewReader([]byte(in)), opts...).Next()
+		},
+		"LinesReader": func(opts ...Option) (JSON, error) {
+			return NewLinesReader(bytes.NewReader([]byte(in)), opts...).Next()
+		},
+	}
+	for name, parse := range parses {
+		t.Run(name, func(t *testing.T) {
+			j, err := parse(KeepNumberLiterals())
+			assert.NoError(t, err)
+			a := j.lits.child("a")
+			assert.Equal(t, []string{"1.50", "-0", "1e3", ""}, []string{
+				a.child(0).literal(), a.child(1).literal(), a.child(2).literal(), a.child(3).literal(),
+			})
+			assert.Equal(t, "2", j.lits.child("b").child("c").literal())
+			assert.Nil(t, j.pos, "positions are not recorded")
+
+			j, err = parse()
+			assert.NoError(t, err)
+			assert.Nil(t, j.lits)
+		})
+	}
+
+	// both are recorded in the same tree
+	j, err := New([]byte(in), KeepNumberLiterals(), RecordPositions())
+	assert.NoError(t, err)
+	p, err := j.Position(".b.c")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", in[p.Value.Start:p.Value.End])
+	assert.Equal(t, "1e3", j.lits.child("a").child(2).literal())
+}
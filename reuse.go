@@ -0,0 +1,95 @@
+package libjson
+
+import (
+	"io"
+)
+
+// Parser parses documents while reusing its internal buffers between calls,
+// such as the structural index, the buffer for reading from an io.Reader and
+// already copied object keys, thus the allocations per parse are mostly
+// limited to the resulting tree. A Parser is not safe for concurrent use, but
+// is intended for use with a sync.Pool:
+//
+//	var pool = sync.Pool{New: func() any { return libjson.NewParser() }}
+//
+//	p := pool.Get().(*libjson.Parser)
+//	defer pool.Put(p)
+//	doc, err := p.ParseReader(r.Body)
+type Parser struct {
+	p parser
+	config
+	idx *structuralIndex
+	buf []byte
+}
+
+// NewParser creates a Parser applying opts to every parse
+func NewParser(opts ...Option) *Parser {
+	return &Parser{
+		p:      parser{keys: make(map[string]string, 64)},
+		config: newConfig(opts),
+		idx:    newStructuralIndex(0),
+	}
+}
+
+// Parse is New, reusing the buffers of ps
+func (ps *Parser) Parse(data []byte) (JSON, error) {
+	l := lexer{data: data, dialect: ps.dialect, nonFinite: ps.allowNonFinite}
+	if ps.dialect == Strict {
+		ps.idx.reset()
+		l.idx = ps.idx
+	}
+	ps.reset(l)
+	if ps.positions || ps.keepLiterals {
+		ps.p.track()
+	}
+	var obj any
+	var err error
+	if ps.p.workers > 1 && ps.p.arena == nil && l.idx != nil && ps.p.tracker == nil {
+		obj, err = ps.p.parallel()
+	} else {
+		obj, err = ps.p.parse()
+	}
+	if err != nil {
+		return JSON{}, err
+	}
+	return JSON{obj: obj, pos: ps.p.recorded(), lits: ps.p.literals()}, nil
+}
+
+// ParseReader is NewReader, reusing the buffers of ps
+func (ps *Parser) ParseReader(r io.Reader) (JSON, error) {
+	if ps.buf == nil {
+		ps.buf = make([]byte, 0, bufSize)
+	}
+	ps.reset(lexer{r: r, data: ps.buf[:0], dialect: ps.dialect, nonFinite: ps.allowNonFinite})
+	if ps.positions || ps.keepLiterals {
+		ps.p.track()
+	}
+	obj, err := ps.p.parse()
+	// keep the buffer if the lexer had to grow it
+	ps.buf = ps.p.l.data[:0]
+	if err != nil {
+		return JSON{}, err
+	}
+	return JSON{obj: obj, pos: ps.p.recorded(), lits: ps.p.literals()}, nil
+}
+
+// reset prepares the parser for a new input, keeping its buffers
+func (ps *Parser) reset(l lexer) {
+	ps.p = parser{
+		l:      l,
+		config: ps.config,
+		stack:  ps.p.stack[:0],
+		keys:   ps.p.keys,
+	}
+}
+
+// Reset drops all references of ps to the previously parsed input, call it
+// before putting ps back into a pool to not keep the input alive. If ps was
+// created with UseArena, the arena is reset as well, invalidating all trees
+// parsed with ps.
+func (ps *Parser) Reset() {
+	ps.reset(lexer{})
+	if ps.arena != nil {
+		ps.arena.Reset()
+	}
+}
@@ -0,0 +1,92 @@
+package libjson
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParserReuse(t *testing.T) {
+	input := []string{
+		`{"key": [1, 2, {"key": "value"}]}`,
+		"[1, 2, 3]",
+		`"str"`,
+		string(genData(1)),
+		`{"key": "value"}`,
+	}
+	ps := NewParser()
+	for _, in := range input {
+		want, err := New([]byte(in))
+		assert.NoError(t, err)
+
+		got, err := ps.Parse([]byte(in))
+		assert.NoError(t, err)
+		assert.EqualValues(t, want, got)
+
+		got, err = ps.ParseReader(strings.NewReader(in))
+		assert.NoError(t, err)
+		assert.EqualValues(t, want, got)
+	}
+
+	_, err := ps.Parse([]byte(`{"key": }`))
+	assert.Error(t, err)
+	got, err := ps.Parse([]byte(`{"key": 1}`))
+	assert.NoError(t, err, "errors do not carry over")
+	assert.EqualValues(t, map[string]any{"key": 1.0}, got.obj)
+
+	ps.Reset()
+	assert.Nil(t, ps.p.l.data)
+}
+
+func TestParserReuseAllocs(t *testing.T) {
+	d := []byte(`{"key": [1, 2, {"key": "value"}], "other": [true, false, null]}`)
+	ps := NewParser(UseArena(NewArena()))
+	// warm up the buffers
+	_, err := ps.Parse(d)
+	assert.NoError(t, err)
+
+	fresh := testing.AllocsPerRun(10, func() {
+		_, _ = New(d)
+	})
+	reused := testing.AllocsPerRun(10, func() {
+		ps.Reset()
+		_, _ = ps.Parse(d)
+	})
+	// only the maps of the two objects remain
+	assert.Less(t, reused, fresh)
+	assert.LessOrEqual(t, reused, 4.0)
+
+	r := bytes.NewReader(d)
+	reader := testing.AllocsPerRun(10, func() {
+		ps.Reset()
+		r.Reset(d)
+		_, _ = ps.ParseReader(r)
+	})
+	// keys are reused from the previous parses, only "value" is copied
+	assert.LessOrEqual(t, reader, 5.0)
+}
+
+func TestParserPool(t *testing.T) {
+	pool := sync.Pool{New: func() any { return NewParser() }}
+	d := genData(1)
+	want, err := New(d)
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ps := pool.Get().(*Parser)
+			defer pool.Put(ps)
+			defer ps.Reset()
+			got, err := ps.ParseReader(bytes.NewReader(d))
+			assert.NoError(t, err)
+			assert.EqualValues(t, want, got)
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,439 @@
+package schema
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// node is a compiled schema or subschema
+type node struct {
+	// JSON Pointer of the schema in the schema document
+	loc string
+	// set for the boolean schemas true and false
+	always, never bool
+
+	types []string
+	enum  []any
+	// constant is only valid if hasConst is set, since it may be null
+	constant any
+	hasConst bool
+
+	multipleOf, maximum, exclusiveMaximum, minimum, exclusiveMinimum *float64
+
+	maxLength, minLength int
+	pattern              *regexp.Regexp
+	format               string
+
+	maxItems, minItems       int
+	uniqueItems              bool
+	prefixItems              []*node
+	items, contains          *node
+	maxContains, minContains int
+
+	maxProperties, minProperties int
+	required                     []string
+	dependentRequired            map[string][]string
+	properties                   []property
+	patternProperties            []patternProperty
+	additionalProperties         *node
+	propertyNames                *node
+	dependentSchemas             map[string]*node
+
+	ref                   string
+	refNode               *node
+	allOf, anyOf, oneOf   []*node
+	not, if_, then, else_ *node
+}
+
+type property struct {
+	name   string
+	schema *node
+}
+
+type patternProperty struct {
+	pattern *regexp.Regexp
+	schema  *node
+}
+
+// compiler compiles a schema document, subschemas are registered by their
+// location for the resolution of references
+type compiler struct {
+	doc any
+	// $id of the root schema
+	id      string
+	nodes   map[string]*node
+	anchors map[string]*node
+}
+
+func (c *compiler) compile(v any, loc string) (*node, error) {
+	if n, ok := c.nodes[loc]; ok {
+		return n, nil
+	}
+	n := &node{loc: loc, maxLength: -1, maxItems: -1, maxContains: -1, minContains: 1, maxProperties: -1}
+	c.nodes[loc] = n
+	switch v := v.(type) {
+	case bool:
+		n.always, n.never = v, !v
+		return n, nil
+	case map[string]any:
+		if err := c.keywords(n, v); err != nil {
+			return nil, err
+		}
+		return n, nil
+	}
+	return nil, fmt.Errorf("Schema at %q must be an object or a boolean, got %s", loc, typeOf(v))
+}
+
+// keywords compiles the keywords of the schema object m into n
+func (c *compiler) keywords(n *node, m map[string]any) error {
+	for _, kw := range []string{"unevaluatedProperties", "unevaluatedItems", "$dynamicRef", "$recursiveRef"} {
+		if _, ok := m[kw]; ok {
+			return fmt.Errorf("Unsupported keyword %q at %q", kw, n.loc)
+		}
+	}
+	var err error
+	kw := keywords{c: c, n: n, m: m}
+
+	if a, ok := m["$anchor"]; ok {
+		name, ok := a.(string)
+		if !ok {
+			return kw.fail("$anchor", "a string")
+		}
+		c.anchors[name] = n
+	}
+	if r, ok := m["$ref"]; ok {
+		if n.ref, ok = r.(string); !ok {
+			return kw.fail("$ref", "a string")
+		}
+	}
+	for _, defs := range []string{"$defs", "definitions"} {
+		if _, err := kw.schemaMap(defs); err != nil {
+			return err
+		}
+	}
+
+	switch t := m["type"].(type) {
+	case nil:
+	case string:
+		n.types = []string{t}
+	case []any:
+		for _, e := range t {
+			s, ok := e.(string)
+			if !ok {
+				return kw.fail("type", "a string or an array of strings")
+			}
+			n.types = append(n.types, s)
+		}
+	default:
+		return kw.fail("type", "a string or an array of strings")
+	}
+	if e, ok := m["enum"]; ok {
+		if n.enum, ok = e.([]any); !ok {
+			return kw.fail("enum", "an array")
+		}
+	}
+	n.constant, n.hasConst = m["const"]
+
+	for name, dst := range map[string]**float64{
+		"multipleOf":       &n.multipleOf,
+		"maximum":          &n.maximum,
+		"exclusiveMaximum": &n.exclusiveMaximum,
+		"minimum":          &n.minimum,
+		"exclusiveMinimum": &n.exclusiveMinimum,
+	} {
+		if *dst, err = kw.number(name); err != nil {
+			return err
+		}
+	}
+	if n.multipleOf != nil && *n.multipleOf <= 0 {
+		return kw.fail("multipleOf", "a number greater than 0")
+	}
+	for name, dst := range map[string]*int{
+		"maxLength":     &n.maxLength,
+		"minLength":     &n.minLength,
+		"maxItems":      &n.maxItems,
+		"minItems":      &n.minItems,
+		"maxContains":   &n.maxContains,
+		"minContains":   &n.minContains,
+		"maxProperties": &n.maxProperties,
+		"minProperties": &n.minProperties,
+	} {
+		if err := kw.count(name, dst); err != nil {
+			return err
+		}
+	}
+
+	if p, ok := m["pattern"]; ok {
+		if n.pattern, err = kw.regexp("pattern", p); err != nil {
+			return err
+		}
+	}
+	if f, ok := m["format"]; ok {
+		if n.format, ok = f.(string); !ok {
+			return kw.fail("format", "a string")
+		}
+	}
+
+	if u, ok := m["uniqueItems"]; ok {
+		if n.uniqueItems, ok = u.(bool); !ok {
+			return kw.fail("uniqueItems", "a boolean")
+		}
+	}
+	if _, ok := m["items"].([]any); ok {
+		return kw.fail("items", "a schema, use prefixItems for tuples")
+	}
+	if n.prefixItems, err = kw.schemas("prefixItems"); err != nil {
+		return err
+	}
+	if n.items, err = kw.schema("items"); err != nil {
+		return err
+	}
+	if n.contains, err = kw.schema("contains"); err != nil {
+		return err
+	}
+
+	if n.required, err = kw.strings("required", m["required"]); err != nil {
+		return err
+	}
+	if d, ok := m["dependentRequired"]; ok {
+		deps, ok := d.(map[string]any)
+		if !ok {
+			return kw.fail("dependentRequired", "an object")
+		}
+		n.dependentRequired = make(map[string][]string, len(deps))
+		for k, v := range deps {
+			if n.dependentRequired[k], err = kw.strings("dependentRequired", v); err != nil {
+				return err
+			}
+		}
+	}
+	props, err := kw.schemaMap("properties")
+	if err != nil {
+		return err
+	}
+	for _, name := range slices.Sorted(maps.Keys(props)) {
+		n.properties = append(n.properties, property{name, props[name]})
+	}
+	patterns, err := kw.schemaMap("patternProperties")
+	if err != nil {
+		return err
+	}
+	for _, p := range slices.Sorted(maps.Keys(patterns)) {
+		re, err := kw.regexp("patternProperties", p)
+		if err != nil {
+			return err
+		}
+		n.patternProperties = append(n.patternProperties, patternProperty{re, patterns[p]})
+	}
+	if n.additionalProperties, err = kw.schema("additionalProperties"); err != nil {
+		return err
+	}
+	if n.propertyNames, err = kw.schema("propertyNames"); err != nil {
+		return err
+	}
+	if n.dependentSchemas, err = kw.schemaMap("dependentSchemas"); err != nil {
+		return err
+	}
+
+	if n.allOf, err = kw.schemas("allOf"); err != nil {
+		return err
+	}
+	if n.anyOf, err = kw.schemas("anyOf"); err != nil {
+		return err
+	}
+	if n.oneOf, err = kw.schemas("oneOf"); err != nil {
+		return err
+	}
+	for name, dst := range map[string]**node{"not": &n.not, "if": &n.if_, "then": &n.then, "else": &n.else_} {
+		if *dst, err = kw.schema(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolve resolves the references of all compiled schemas, compiling the
+// referenced schemas not compiled yet
+func (c *compiler) resolve() error {
+	for {
+		resolved := true
+		for _, n := range c.nodes {
+			if n.ref == "" || n.refNode != nil {
+				continue
+			}
+			target, err := c.lookup(n.ref)
+			if err != nil {
+				return fmt.Errorf("Failed to resolve $ref at %q: %w", n.loc, err)
+			}
+			n.refNode = target
+			resolved = false
+		}
+		// compiling referenced schemas may add new references
+		if resolved {
+			return nil
+		}
+	}
+}
+
+// lookup returns the schema referenced by ref
+func (c *compiler) lookup(ref string) (*node, error) {
+	base, fragment, _ := strings.Cut(ref, "#")
+	if base != "" && base != c.id {
+		return nil, fmt.Errorf("Unsupported reference %q, only references into the same document are resolved", ref)
+	}
+	fragment, err := url.PathUnescape(fragment)
+	if err != nil {
+		return nil, err
+	}
+	if fragment != "" && fragment[0] != '/' {
+		n, ok := c.anchors[fragment]
+		if !ok {
+			return nil, fmt.Errorf("Unknown anchor %q", fragment)
+		}
+		return n, nil
+	}
+	if n, ok := c.nodes[fragment]; ok {
+		return n, nil
+	}
+	// a location not compiled as a subschema, such as a member of an
+	// unknown keyword
+	v := c.doc
+	for _, tok := range strings.Split(fragment, "/")[1:] {
+		tok = strings.NewReplacer("~1", "/", "~0", "~").Replace(tok)
+		switch t := v.(type) {
+		case map[string]any:
+			var ok bool
+			if v, ok = t[tok]; !ok {
+				return nil, fmt.Errorf("No schema at %q", fragment)
+			}
+		case []any:
+			i, err := strconv.Atoi(tok)
+			if err != nil || i < 0 || i >= len(t) {
+				return nil, fmt.Errorf("No schema at %q", fragment)
+			}
+			v = t[i]
+		default:
+			return nil, fmt.Errorf("No schema at %q", fragment)
+		}
+	}
+	return c.compile(v, fragment)
+}
+
+// keywords reads the keywords of the schema object m
+type keywords struct {
+	c *compiler
+	n *node
+	m map[string]any
+}
+
+func (k keywords) fail(name string, expected string) error {
+	return fmt.Errorf("Invalid keyword %q at %q, expected %s", name, k.n.loc, expected)
+}
+
+func (k keywords) number(name string) (*float64, error) {
+	v, ok := k.m[name]
+	if !ok {
+		return nil, nil
+	}
+	f, ok := v.(float64)
+	if !ok {
+		return nil, k.fail(name, "a number")
+	}
+	return &f, nil
+}
+
+func (k keywords) count(name string, dst *int) error {
+	v, ok := k.m[name]
+	if !ok {
+		return nil
+	}
+	f, ok := v.(float64)
+	if !ok || f < 0 || f != math.Trunc(f) {
+		return k.fail(name, "a non-negative integer")
+	}
+	*dst = int(f)
+	return nil
+}
+
+func (k keywords) strings(name string, v any) ([]string, error) {
+	if v == nil {
+		return nil, nil
+	}
+	a, ok := v.([]any)
+	if !ok {
+		return nil, k.fail(name, "an array of strings")
+	}
+	r := make([]string, 0, len(a))
+	for _, e := range a {
+		s, ok := e.(string)
+		if !ok {
+			return nil, k.fail(name, "an array of strings")
+		}
+		r = append(r, s)
+	}
+	return r, nil
+}
+
+func (k keywords) regexp(name string, v any) (*regexp.Regexp, error) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, k.fail(name, "a string")
+	}
+	re, err := regexp.Compile(s)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid regular expression of %q at %q: %w", name, k.n.loc, err)
+	}
+	return re, nil
+}
+
+func (k keywords) schema(name string) (*node, error) {
+	v, ok := k.m[name]
+	if !ok {
+		return nil, nil
+	}
+	return k.c.compile(v, pointer(k.n.loc, name))
+}
+
+func (k keywords) schemas(name string) ([]*node, error) {
+	v, ok := k.m[name]
+	if !ok {
+		return nil, nil
+	}
+	a, ok := v.([]any)
+	if !ok || len(a) == 0 {
+		return nil, k.fail(name, "a non-empty array of schemas")
+	}
+	r := make([]*node, len(a))
+	for i, e := range a {
+		var err error
+		if r[i], err = k.c.compile(e, pointer(k.n.loc, name)+"/"+strconv.Itoa(i)); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (k keywords) schemaMap(name string) (map[string]*node, error) {
+	v, ok := k.m[name]
+	if !ok {
+		return nil, nil
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, k.fail(name, "an object of schemas")
+	}
+	r := make(map[string]*node, len(m))
+	for key, e := range m {
+		var err error
+		if r[key], err = k.c.compile(e, pointer(pointer(k.n.loc, name), key)); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
@@ -0,0 +1,88 @@
+package schema
+
+import (
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/xnacly/libjson/internal/format"
+)
+
+// formats validates the strings of the format keyword, unknown formats are
+// ignored as mandated by the specification
+var formats = map[string]func(string) bool{
+	"date-time": func(s string) bool {
+		_, err := time.Parse(time.RFC3339Nano, strings.ToUpper(s))
+		return err == nil
+	},
+	"date": func(s string) bool {
+		_, err := time.Parse(time.DateOnly, s)
+		return err == nil
+	},
+	"time": func(s string) bool {
+		_, err := time.Parse("15:04:05.999999999Z07:00", strings.ToUpper(s))
+		return err == nil
+	},
+	"duration": func(s string) bool {
+		// every component ends with its designator, thus "P", "PT" and
+		// "P1DT" lack one
+		return duration.MatchString(s) && !strings.HasSuffix(s, "P") && !strings.HasSuffix(s, "T")
+	},
+	"email": func(s string) bool {
+		a, err := mail.ParseAddress(s)
+		// reject display names, such as "Name <a@b.c>"
+		return err == nil && a.Address == s
+	},
+	"hostname": hostname,
+	"ipv4": func(s string) bool {
+		a, err := netip.ParseAddr(s)
+		return err == nil && a.Is4()
+	},
+	"ipv6": func(s string) bool {
+		a, err := netip.ParseAddr(s)
+		return err == nil && a.Is6()
+	},
+	"uri": func(s string) bool {
+		u, err := url.Parse(s)
+		return err == nil && u.IsAbs()
+	},
+	"uri-reference": func(s string) bool {
+		_, err := url.Parse(s)
+		return err == nil
+	},
+	"uuid": format.UUID.MatchString,
+	"regex": func(s string) bool {
+		_, err := regexp.Compile(s)
+		return err == nil
+	},
+	"json-pointer": func(s string) bool {
+		return s == "" || (s[0] == '/' && !invalidEscape.MatchString(s))
+	},
+}
+
+var (
+	duration      = regexp.MustCompile(`^P(?:\d+W|(?:\d+Y)?(?:\d+M)?(?:\d+D)?(?:T(?:\d+H)?(?:\d+M)?(?:\d+S)?)?)$`)
+	invalidEscape = regexp.MustCompile(`~[^01]|~$`)
+)
+
+// hostname validates s as specified by rfc1123
+func hostname(s string) bool {
+	s = strings.TrimSuffix(s, ".")
+	if len(s) == 0 || len(s) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(s, ".") {
+		if len(label) == 0 || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range []byte(label) {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
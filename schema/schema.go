@@ -0,0 +1,165 @@
+// Package schema validates json documents parsed by libjson against JSON
+// Schema (draft 2020-12) documents:
+//
+//	s, err := schema.CompileBytes([]byte(`{"type": "object", "required": ["name"]}`))
+//	doc, err := libjson.New(body)
+//	if err := s.Validate(&doc); err != nil {
+//		for _, e := range err.(*schema.ValidationError).Errors {
+//			fmt.Println(e.InstanceLocation, e.Message)
+//		}
+//	}
+//
+// Supported are the assertions of the validation vocabulary, including the
+// formats date-time, date, time, duration, email, hostname, ipv4, ipv6, uri,
+// uri-reference, uuid, regex and json-pointer, as well as the applicators
+// properties, patternProperties, additionalProperties, propertyNames,
+// dependentSchemas, prefixItems, items, contains, allOf, anyOf, oneOf, not and
+// if/then/else. $ref is resolved for references into the same document, via
+// JSON Pointers, $anchor or the $id of the root schema. Patterns are compiled
+// via regexp, thus use the RE2 instead of the ECMA 262 syntax.
+// unevaluatedProperties, unevaluatedItems, $dynamicRef and references to
+// other documents are not supported and rejected by Compile.
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xnacly/libjson"
+)
+
+// Schema is a compiled JSON Schema, safe for concurrent use
+type Schema struct {
+	root *node
+}
+
+// Error is a failed assertion of a schema
+type Error struct {
+	// JSON Pointer (rfc6901) to the value failing the assertion, the empty
+	// string for the top level value
+	InstanceLocation string
+	// JSON Pointer to the keyword of the failed assertion in the schema
+	// document
+	SchemaLocation string
+	Message        string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s at %q (schema %q)", e.Message, e.InstanceLocation, e.SchemaLocation)
+}
+
+// ValidationError holds all failed assertions of a document, returned by
+// Schema.Validate
+type ValidationError struct {
+	Errors []*Error
+}
+
+func (e *ValidationError) Error() string {
+	b := strings.Builder{}
+	for i, err := range e.Errors {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
+
+// Compile compiles the schema document doc
+func Compile(doc *libjson.JSON) (*Schema, error) {
+	c := compiler{
+		doc:     doc.Root().Interface(),
+		nodes:   map[string]*node{},
+		anchors: map[string]*node{},
+	}
+	if m, ok := c.doc.(map[string]any); ok {
+		c.id, _ = m["$id"].(string)
+	}
+	root, err := c.compile(c.doc, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := c.resolve(); err != nil {
+		return nil, err
+	}
+	return &Schema{root: root}, nil
+}
+
+// CompileBytes parses and compiles the schema document data
+func CompileBytes(data []byte) (*Schema, error) {
+	doc, err := libjson.New(data, libjson.CopyStrings())
+	if err != nil {
+		return nil, err
+	}
+	return Compile(&doc)
+}
+
+// Validate validates doc, all failed assertions are returned as a
+// *ValidationError
+func (s *Schema) Validate(doc *libjson.JSON) error {
+	st := state{}
+	s.root.validate(doc.Root().Interface(), "", &st)
+	if len(st.errs) > 0 {
+		return &ValidationError{Errors: st.errs}
+	}
+	return nil
+}
+
+// pointer appends the reference token tok to the JSON Pointer p, escaping
+// '~' and '/'
+func pointer(p string, tok string) string {
+	if strings.ContainsAny(tok, "~/") {
+		tok = strings.NewReplacer("~", "~0", "/", "~1").Replace(tok)
+	}
+	return p + "/" + tok
+}
+
+// typeOf returns the JSON Schema type of v, integers are reported as
+// "number"
+func typeOf(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// equal reports whether a and b are the same json value
+func equal(a, b any) bool {
+	switch a := a.(type) {
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, v := range a {
+			w, ok := b[k]
+			if !ok || !equal(v, w) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
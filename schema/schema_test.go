@@ -0,0 +1,208 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xnacly/libjson"
+)
+
+func validate(t *testing.T, schema string, instance string) error {
+	t.Helper()
+	s, err := CompileBytes([]byte(schema))
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	doc, err := libjson.New([]byte(instance))
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	return s.Validate(&doc)
+}
+
+func TestValidate(t *testing.T) {
+	input := []struct {
+		schema   string
+		instance string
+		valid    bool
+	}{
+		{`true`, `[1]`, true},
+		{`false`, `null`, false},
+		{`{}`, `{"a": 1}`, true},
+		{`{"type": "string"}`, `"a"`, true},
+		{`{"type": "string"}`, `1`, false},
+		{`{"type": "integer"}`, `1.0`, true},
+		{`{"type": "integer"}`, `1.5`, false},
+		{`{"type": "number"}`, `1`, true},
+		{`{"type": ["null", "boolean"]}`, `false`, true},
+		{`{"type": ["null", "boolean"]}`, `{}`, false},
+		{`{"enum": [1, "a", {"b": [null]}]}`, `{"b": [null]}`, true},
+		{`{"enum": [1, "a"]}`, `"b"`, false},
+		{`{"const": null}`, `null`, true},
+		{`{"const": null}`, `false`, false},
+
+		{`{"multipleOf": 0.1}`, `0.3`, true},
+		{`{"multipleOf": 2}`, `3`, false},
+		{`{"maximum": 3}`, `3`, true},
+		{`{"exclusiveMaximum": 3}`, `3`, false},
+		{`{"minimum": 3}`, `2.9`, false},
+		{`{"exclusiveMinimum": 3}`, `3.1`, true},
+		{`{"minimum": 3}`, `"not a number"`, true},
+
+		{`{"maxLength": 2}`, `"äö"`, true},
+		{`{"maxLength": 2}`, `"abc"`, false},
+		{`{"minLength": 1}`, `""`, false},
+		{`{"pattern": "^a+$"}`, `"aaa"`, true},
+		{`{"pattern": "^a+$"}`, `"aba"`, false},
+
+		{`{"format": "date-time"}`, `"2024-01-31T12:00:00.5+01:00"`, true},
+		{`{"format": "date-time"}`, `"2024-01-31 12:00:00"`, false},
+		{`{"format": "date"}`, `"2024-02-30"`, false},
+		{`{"format": "time"}`, `"23:59:59Z"`, true},
+		{`{"format": "duration"}`, `"P1DT2H"`, true},
+		{`{"format": "duration"}`, `"1D"`, false},
+		{`{"format": "duration"}`, `"P2W"`, true},
+		{`{"format": "duration"}`, `"PT0S"`, true},
+		{`{"format": "duration"}`, `"P"`, false},
+		{`{"format": "duration"}`, `"PT"`, false},
+		{`{"format": "duration"}`, `"P1DT"`, false},
+		{`{"format": "email"}`, `"user@example.com"`, true},
+		{`{"format": "email"}`, `"User <user@example.com>"`, false},
+		{`{"format": "hostname"}`, `"example.com"`, true},
+		{`{"format": "hostname"}`, `"-example.com"`, false},
+		{`{"format": "ipv4"}`, `"127.0.0.1"`, true},
+		{`{"format": "ipv4"}`, `"::1"`, false},
+		{`{"format": "ipv6"}`, `"::1"`, true},
+		{`{"format": "uri"}`, `"https://example.com/a?b#c"`, true},
+		{`{"format": "uri"}`, `"/relative"`, false},
+		{`{"format": "uri-reference"}`, `"/relative"`, true},
+		{`{"format": "uuid"}`, `"123e4567-e89b-12d3-a456-426614174000"`, true},
+		{`{"format": "uuid"}`, `"123e4567"`, false},
+		{`{"format": "regex"}`, `"(unclosed"`, false},
+		{`{"format": "json-pointer"}`, `"/a~1b/0"`, true},
+		{`{"format": "json-pointer"}`, `"/a~2"`, false},
+		{`{"format": "unknown"}`, `"anything"`, true},
+
+		{`{"maxItems": 1}`, `[1, 2]`, false},
+		{`{"minItems": 1}`, `[]`, false},
+		{`{"uniqueItems": true}`, `[1, {"a": 1}, {"a": 2}]`, true},
+		{`{"uniqueItems": true}`, `[1, {"a": 1}, {"a": 1}]`, false},
+		{`{"items": {"type": "number"}}`, `[1, 2]`, true},
+		{`{"items": {"type": "number"}}`, `[1, "2"]`, false},
+		{`{"prefixItems": [{"type": "string"}], "items": false}`, `["a"]`, true},
+		{`{"prefixItems": [{"type": "string"}], "items": false}`, `["a", 1]`, false},
+		{`{"prefixItems": [{"type": "string"}]}`, `[1]`, false},
+		{`{"contains": {"const": 1}}`, `[0, 1]`, true},
+		{`{"contains": {"const": 1}}`, `[0, 2]`, false},
+		{`{"contains": {"const": 1}, "minContains": 2}`, `[1, 0, 1]`, true},
+		{`{"contains": {"const": 1}, "maxContains": 1}`, `[1, 1]`, false},
+		{`{"contains": {"const": 1}, "minContains": 0}`, `[]`, true},
+
+		{`{"required": ["a"]}`, `{"a": null}`, true},
+		{`{"required": ["a"]}`, `{"b": 1}`, false},
+		{`{"required": ["a"]}`, `[]`, true},
+		{`{"maxProperties": 1}`, `{"a": 1, "b": 2}`, false},
+		{`{"minProperties": 1}`, `{}`, false},
+		{`{"properties": {"a": {"type": "string"}}}`, `{"a": "x", "b": 1}`, true},
+		{`{"properties": {"a": {"type": "string"}}}`, `{"a": 1}`, false},
+		{`{"patternProperties": {"^x-": {"type": "string"}}}`, `{"x-a": 1}`, false},
+		{`{"properties": {"a": true}, "additionalProperties": false}`, `{"a": 1}`, true},
+		{`{"properties": {"a": true}, "additionalProperties": false}`, `{"a": 1, "b": 2}`, false},
+		{`{"patternProperties": {"^x-": true}, "additionalProperties": false}`, `{"x-a": 1}`, true},
+		{`{"propertyNames": {"maxLength": 2}}`, `{"abc": 1}`, false},
+		{`{"dependentRequired": {"a": ["b"]}}`, `{"a": 1}`, false},
+		{`{"dependentRequired": {"a": ["b"]}}`, `{"c": 1}`, true},
+		{`{"dependentSchemas": {"a": {"required": ["b"]}}}`, `{"a": 1, "b": 2}`, true},
+		{`{"dependentSchemas": {"a": {"required": ["b"]}}}`, `{"a": 1}`, false},
+
+		{`{"allOf": [{"type": "number"}, {"minimum": 2}]}`, `1`, false},
+		{`{"anyOf": [{"type": "string"}, {"type": "number"}]}`, `1`, true},
+		{`{"anyOf": [{"type": "string"}, {"type": "number"}]}`, `null`, false},
+		{`{"oneOf": [{"type": "number"}, {"minimum": 2}]}`, `1`, true},
+		{`{"oneOf": [{"type": "number"}, {"minimum": 2}]}`, `3`, false},
+		{`{"not": {"type": "null"}}`, `null`, false},
+		{`{"if": {"type": "string"}, "then": {"minLength": 2}, "else": {"type": "number"}}`, `"ab"`, true},
+		{`{"if": {"type": "string"}, "then": {"minLength": 2}, "else": {"type": "number"}}`, `"a"`, false},
+		{`{"if": {"type": "string"}, "then": {"minLength": 2}, "else": {"type": "number"}}`, `null`, false},
+		{`{"if": {"type": "string"}, "then": {"minLength": 2}}`, `null`, true},
+
+		{`{"$defs": {"pos": {"minimum": 0}}, "$ref": "#/$defs/pos"}`, `1`, true},
+		{`{"$defs": {"pos": {"minimum": 0}}, "$ref": "#/$defs/pos"}`, `-1`, false},
+		{`{"$defs": {"a~/b": {"type": "null"}}, "$ref": "#/$defs/a~0~1b"}`, `null`, true},
+		{`{"$defs": {"a": {"$anchor": "x", "type": "null"}}, "$ref": "#x"}`, `1`, false},
+		{`{"$id": "https://example.com/s", "$defs": {"a": {"type": "null"}}, "$ref": "https://example.com/s#/$defs/a"}`, `null`, true},
+		{`{"x": {"type": "null"}, "$ref": "#/x"}`, `1`, false},
+		{`{"type": "object", "properties": {"next": {"$ref": "#"}}, "additionalProperties": false}`, `{"next": {"next": {}}}`, true},
+		{`{"type": "object", "properties": {"next": {"$ref": "#"}}, "additionalProperties": false}`, `{"next": {"next": {"b": 1}}}`, false},
+	}
+	for _, i := range input {
+		t.Run(i.schema+" "+i.instance, func(t *testing.T) {
+			err := validate(t, i.schema, i.instance)
+			if i.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	schema := `{
+		"$defs": {"name": {"type": "string", "minLength": 1}},
+		"type": "object",
+		"required": ["id"],
+		"properties": {
+			"users": {"items": {"properties": {"name": {"$ref": "#/$defs/name"}}}},
+			"a/b": {"type": "null"}
+		}
+	}`
+	err := validate(t, schema, `{"users": [{"name": "x"}, {"name": ""}, {"name": 1}], "a/b": 0}`)
+	var verr *ValidationError
+	assert.ErrorAs(t, err, &verr)
+	want := []Error{
+		{InstanceLocation: "", SchemaLocation: "/required"},
+		{InstanceLocation: "/a~1b", SchemaLocation: "/properties/a~1b/type"},
+		{InstanceLocation: "/users/1/name", SchemaLocation: "/$defs/name/minLength"},
+		{InstanceLocation: "/users/2/name", SchemaLocation: "/$defs/name/type"},
+	}
+	got := make([]Error, len(verr.Errors))
+	for i, e := range verr.Errors {
+		assert.NotEmpty(t, e.Message)
+		got[i] = Error{InstanceLocation: e.InstanceLocation, SchemaLocation: e.SchemaLocation}
+	}
+	assert.Equal(t, want, got)
+	assert.Contains(t, err.Error(), `Missing required property "id" at "" (schema "/required")`)
+}
+
+func TestValidateCyclicRef(t *testing.T) {
+	err := validate(t, `{"$defs": {"a": {"$ref": "#/$defs/b"}, "b": {"$ref": "#/$defs/a"}}, "$ref": "#/$defs/a"}`, `1`)
+	assert.ErrorContains(t, err, "references are cyclic")
+}
+
+func TestCompileFail(t *testing.T) {
+	input := []string{
+		`1`,
+		`{"type": 1}`,
+		`{"minLength": -1}`,
+		`{"maxItems": 1.5}`,
+		`{"multipleOf": 0}`,
+		`{"pattern": "("}`,
+		`{"required": [1]}`,
+		`{"allOf": []}`,
+		`{"items": [true]}`,
+		`{"properties": {"a": 1}}`,
+		`{"$ref": "#/$defs/missing"}`,
+		`{"$ref": "#missing"}`,
+		`{"$ref": "other.json"}`,
+		`{"unevaluatedProperties": false}`,
+		`{"$dynamicRef": "#meta"}`,
+		`{"not": "a"}`,
+	}
+	for _, i := range input {
+		t.Run(i, func(t *testing.T) {
+			_, err := CompileBytes([]byte(i))
+			assert.Error(t, err)
+		})
+	}
+}
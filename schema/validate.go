@@ -0,0 +1,263 @@
+package schema
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maximum amount of $ref followed without descending into the instance,
+// guards against cyclic references such as {"$ref": "#"}
+const maxRefDepth = 256
+
+// state collects the failed assertions of a validation
+type state struct {
+	errs []*Error
+	// amount of references followed for the current instance location
+	refs int
+}
+
+func (s *state) fail(n *node, keyword string, inst string, format string, args ...any) {
+	s.errs = append(s.errs, &Error{
+		InstanceLocation: inst,
+		SchemaLocation:   pointer(n.loc, keyword),
+		Message:          fmt.Sprintf(format, args...),
+	})
+}
+
+// valid reports whether v is valid against n, discarding the failed
+// assertions
+func (n *node) valid(v any, inst string, s *state) bool {
+	sub := state{refs: s.refs}
+	n.validate(v, inst, &sub)
+	return len(sub.errs) == 0
+}
+
+// validate validates the value v at the instance location inst against n
+func (n *node) validate(v any, inst string, s *state) {
+	if n.always {
+		return
+	} else if n.never {
+		s.errs = append(s.errs, &Error{InstanceLocation: inst, SchemaLocation: n.loc, Message: "No value is valid against the schema false"})
+		return
+	}
+
+	if n.refNode != nil {
+		if s.refs++; s.refs > maxRefDepth {
+			s.fail(n, "$ref", inst, "Exceeded the maximum depth of %d references, the references are cyclic", maxRefDepth)
+			return
+		}
+		n.refNode.validate(v, inst, s)
+		s.refs--
+	}
+
+	if len(n.types) > 0 && !slices.ContainsFunc(n.types, func(t string) bool { return hasType(v, t) }) {
+		s.fail(n, "type", inst, "Expected a value of type %s, got %s", strings.Join(n.types, " or "), typeOf(v))
+	}
+	if n.enum != nil && !slices.ContainsFunc(n.enum, func(e any) bool { return equal(v, e) }) {
+		s.fail(n, "enum", inst, "Value is not one of the enumerated values")
+	}
+	if n.hasConst && !equal(v, n.constant) {
+		s.fail(n, "const", inst, "Value does not equal the constant value")
+	}
+
+	switch v := v.(type) {
+	case float64:
+		n.number(v, inst, s)
+	case string:
+		n.string(v, inst, s)
+	case []any:
+		n.array(v, inst, s)
+	case map[string]any:
+		n.object(v, inst, s)
+	}
+
+	for _, sub := range n.allOf {
+		sub.validate(v, inst, s)
+	}
+	if n.anyOf != nil && !slices.ContainsFunc(n.anyOf, func(sub *node) bool { return sub.valid(v, inst, s) }) {
+		s.fail(n, "anyOf", inst, "Value is not valid against any schema of anyOf")
+	}
+	if n.oneOf != nil {
+		matches := 0
+		for _, sub := range n.oneOf {
+			if sub.valid(v, inst, s) {
+				matches++
+			}
+		}
+		if matches != 1 {
+			s.fail(n, "oneOf", inst, "Value is valid against %d schemas of oneOf, expected exactly one", matches)
+		}
+	}
+	if n.not != nil && n.not.valid(v, inst, s) {
+		s.fail(n, "not", inst, "Value must not be valid against the schema of not")
+	}
+	if n.if_ != nil {
+		if n.if_.valid(v, inst, s) {
+			if n.then != nil {
+				n.then.validate(v, inst, s)
+			}
+		} else if n.else_ != nil {
+			n.else_.validate(v, inst, s)
+		}
+	}
+}
+
+func (n *node) number(v float64, inst string, s *state) {
+	if n.multipleOf != nil {
+		q := v / *n.multipleOf
+		if math.IsInf(q, 0) || math.Abs(q-math.Round(q)) > 1e-9*math.Max(1, math.Abs(q)) {
+			s.fail(n, "multipleOf", inst, "Expected a multiple of %v, got %v", *n.multipleOf, v)
+		}
+	}
+	if n.maximum != nil && v > *n.maximum {
+		s.fail(n, "maximum", inst, "Expected a number less than or equal to %v, got %v", *n.maximum, v)
+	}
+	if n.exclusiveMaximum != nil && v >= *n.exclusiveMaximum {
+		s.fail(n, "exclusiveMaximum", inst, "Expected a number less than %v, got %v", *n.exclusiveMaximum, v)
+	}
+	if n.minimum != nil && v < *n.minimum {
+		s.fail(n, "minimum", inst, "Expected a number greater than or equal to %v, got %v", *n.minimum, v)
+	}
+	if n.exclusiveMinimum != nil && v <= *n.exclusiveMinimum {
+		s.fail(n, "exclusiveMinimum", inst, "Expected a number greater than %v, got %v", *n.exclusiveMinimum, v)
+	}
+}
+
+func (n *node) string(v string, inst string, s *state) {
+	if n.maxLength >= 0 || n.minLength > 0 {
+		l := utf8.RuneCountInString(v)
+		if n.maxLength >= 0 && l > n.maxLength {
+			s.fail(n, "maxLength", inst, "Expected at most %d characters, got %d", n.maxLength, l)
+		}
+		if l < n.minLength {
+			s.fail(n, "minLength", inst, "Expected at least %d characters, got %d", n.minLength, l)
+		}
+	}
+	if n.pattern != nil && !n.pattern.MatchString(v) {
+		s.fail(n, "pattern", inst, "String does not match the pattern %q", n.pattern)
+	}
+	if n.format != "" {
+		if f, ok := formats[n.format]; ok && !f(v) {
+			s.fail(n, "format", inst, "String is not a valid %s", n.format)
+		}
+	}
+}
+
+func (n *node) array(v []any, inst string, s *state) {
+	if n.maxItems >= 0 && len(v) > n.maxItems {
+		s.fail(n, "maxItems", inst, "Expected at most %d items, got %d", n.maxItems, len(v))
+	}
+	if len(v) < n.minItems {
+		s.fail(n, "minItems", inst, "Expected at least %d items, got %d", n.minItems, len(v))
+	}
+	if n.uniqueItems {
+	unique:
+		for i := range v {
+			for j := i + 1; j < len(v); j++ {
+				if equal(v[i], v[j]) {
+					s.fail(n, "uniqueItems", inst, "Expected unique items, the items at %d and %d are equal", i, j)
+					break unique
+				}
+			}
+		}
+	}
+
+	// descending into the instance resets the reference depth
+	refs := s.refs
+	s.refs = 0
+	for i, e := range v {
+		loc := inst + "/" + strconv.Itoa(i)
+		if i < len(n.prefixItems) {
+			n.prefixItems[i].validate(e, loc, s)
+		} else if n.items != nil {
+			n.items.validate(e, loc, s)
+		}
+	}
+	if n.contains != nil {
+		matches := 0
+		for i, e := range v {
+			if n.contains.valid(e, inst+"/"+strconv.Itoa(i), s) {
+				matches++
+			}
+		}
+		if matches < n.minContains {
+			s.fail(n, "contains", inst, "Expected at least %d items valid against the schema of contains, got %d", n.minContains, matches)
+		}
+		if n.maxContains >= 0 && matches > n.maxContains {
+			s.fail(n, "maxContains", inst, "Expected at most %d items valid against the schema of contains, got %d", n.maxContains, matches)
+		}
+	}
+	s.refs = refs
+}
+
+func (n *node) object(v map[string]any, inst string, s *state) {
+	if n.maxProperties >= 0 && len(v) > n.maxProperties {
+		s.fail(n, "maxProperties", inst, "Expected at most %d properties, got %d", n.maxProperties, len(v))
+	}
+	if len(v) < n.minProperties {
+		s.fail(n, "minProperties", inst, "Expected at least %d properties, got %d", n.minProperties, len(v))
+	}
+	for _, name := range n.required {
+		if _, ok := v[name]; !ok {
+			s.fail(n, "required", inst, "Missing required property %q", name)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(n.dependentRequired)) {
+		if _, ok := v[name]; !ok {
+			continue
+		}
+		for _, dep := range n.dependentRequired[name] {
+			if _, ok := v[dep]; !ok {
+				s.fail(n, "dependentRequired", inst, "Missing property %q, required by the property %q", dep, name)
+			}
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(n.dependentSchemas)) {
+		if _, ok := v[name]; ok {
+			n.dependentSchemas[name].validate(v, inst, s)
+		}
+	}
+
+	refs := s.refs
+	s.refs = 0
+	for _, p := range n.properties {
+		if e, ok := v[p.name]; ok {
+			p.schema.validate(e, pointer(inst, p.name), s)
+		}
+	}
+	if n.patternProperties == nil && n.additionalProperties == nil && n.propertyNames == nil {
+		s.refs = refs
+		return
+	}
+	for _, name := range slices.Sorted(maps.Keys(v)) {
+		loc := pointer(inst, name)
+		if n.propertyNames != nil {
+			n.propertyNames.validate(name, loc, s)
+		}
+		matched := slices.ContainsFunc(n.properties, func(p property) bool { return p.name == name })
+		for _, p := range n.patternProperties {
+			if p.pattern.MatchString(name) {
+				p.schema.validate(v[name], loc, s)
+				matched = true
+			}
+		}
+		if !matched && n.additionalProperties != nil {
+			n.additionalProperties.validate(v[name], loc, s)
+		}
+	}
+	s.refs = refs
+}
+
+// hasType reports whether v is of the JSON Schema type t
+func hasType(v any, t string) bool {
+	if t == "integer" {
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f) && !math.IsInf(f, 0)
+	}
+	return typeOf(v) == t
+}
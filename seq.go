@@ -0,0 +1,112 @@
+package libjson
+
+import (
+	"io"
+)
+
+// SeqReader reads a stream of multiple top level values, these can either be
+// concatenated ({}{}[]), separated by whitespace or framed as RFC 7464 json
+// text sequences (each value prefixed with the record separator 0x1E)
+type SeqReader struct {
+	p       parser
+	started bool
+	// the input contained at least a single record separator
+	framed bool
+	// skip the rest of the current record before reading the next value
+	resync bool
+	err    error
+}
+
+// NewSeqReader reads the values of r, opts apply as for NewReader
+func NewSeqReader(r io.Reader, opts ...Option) *SeqReader {
+	p := parser{config: newConfig(opts)}
+	p.l = lexer{r: r, data: make([]byte, 0, bufSize), rs: true, dialect: p.dialect, nonFinite: p.allowNonFinite}
+	if p.intern {
+		p.keys = make(map[string]string, 64)
+	}
+	return &SeqReader{p: p}
+}
+
+func (s *SeqReader) fail(err error) (JSON, error) {
+	s.err = err
+	return JSON{}, err
+}
+
+// Next returns the next top level value or io.EOF once the input is
+// exhausted. For RFC 7464 input an invalid or truncated record is reported
+// and calling Next again continues with the following record, otherwise
+// errors are final.
+func (s *SeqReader) Next() (JSON, error) {
+	if s.err != nil {
+		return JSON{}, s.err
+	}
+	if !s.started {
+		s.started = true
+		if err := s.p.advance(); err != nil {
+			return s.fail(err)
+		}
+	}
+	if s.resync {
+		s.resync = false
+		for !s.p.l.record {
+			err := s.p.advance()
+			if err != nil {
+				if s.p.l.err != nil && s.p.l.err != io.EOF {
+					return s.fail(err)
+				}
+				continue
+			}
+			if s.p.cur_tok.Type == t_eof {
+				break
+			}
+		}
+	}
+	for s.p.cur_tok.Type == t_eof && s.p.l.record {
+		s.p.l.record = false
+		s.framed = true
+		if err := s.p.advance(); err != nil {
+			return s.fail(err)
+		}
+	}
+	if s.p.cur_tok.Type == t_eof {
+		return JSON{}, io.EOF
+	}
+
+	if s.p.keepLiterals {
+		s.p.tracker = &tracker{literals: true}
+	}
+	obj, err := s.p.expression()
+	if err != nil {
+		if s.framed && (s.p.l.err == nil || s.p.l.err == io.EOF) {
+			s.resync = true
+			return JSON{}, err
+		}
+		return s.fail(err)
+	}
+	return JSON{obj: obj, lits: s.p.literals()}, nil
+}
+
+// SeqWriter writes RFC 7464 json text sequences
+type SeqWriter struct {
+	w   io.Writer
+	buf []byte
+	config
+}
+
+// NewSeqWriter writes values encoded via (*JSON).Append with opts to w,
+// thus unlike MarshalJSON '<', '>' and '&' are not escaped for html and
+// non-finite numbers are encoded as configured via EncodeNonFinite
+func NewSeqWriter(w io.Writer, opts ...Option) *SeqWriter {
+	return &SeqWriter{w: w, config: newConfig(opts)}
+}
+
+// Write writes the record separator, j and a newline
+func (sw *SeqWriter) Write(j *JSON) error {
+	b, err := appendValue(append(sw.buf[:0], 0x1E), j.obj, &sw.config)
+	if err != nil {
+		return err
+	}
+	sw.buf = append(b, '\n')
+	_, err = sw.w.Write(sw.buf)
+	return err
+}
@@ -0,0 +1,78 @@
+package libjson
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeqReader(t *testing.T) {
+	input := []string{
+		`{}{"a":1}[]"str"[1]`,
+		"{}\n{\"a\":1} []\t\"str\"\r\n[1]",
+		"\x1E{}\n\x1E{\"a\":1}\n\x1E[]\n\x1E\"str\"\n\x1E[1]\n",
+	}
+	wanted := []any{map[string]any{}, map[string]any{"a": 1.0}, []any{}, "str", []any{1.0}}
+	for _, in := range input {
+		t.Run(in, func(t *testing.T) {
+			s := NewSeqReader(strings.NewReader(in))
+			got := []any{}
+			for {
+				j, err := s.Next()
+				if err == io.EOF {
+					break
+				}
+				assert.NoError(t, err)
+				got = append(got, j.Root().Interface())
+			}
+			assert.Equal(t, wanted, got)
+		})
+	}
+}
+
+func TestSeqReaderTruncated(t *testing.T) {
+	in := "\x1E{\"a\":\n\x1E1\n\x1E[1 2]\n\x1E\"str\"\n\x1E{"
+	s := NewSeqReader(strings.NewReader(in))
+	got := []any{}
+	errs := 0
+	for {
+		j, err := s.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			errs++
+			continue
+		}
+		got = append(got, j.Root().Interface())
+	}
+	assert.Equal(t, []any{1.0, "str"}, got)
+	assert.Equal(t, 3, errs)
+}
+
+func TestSeqReaderFail(t *testing.T) {
+	s := NewSeqReader(strings.NewReader(`{}{"a" 1}{}`))
+	_, err := s.Next()
+	assert.NoError(t, err)
+	_, err = s.Next()
+	assert.Error(t, err)
+	_, err = s.Next()
+	assert.Error(t, err, "errors are final for input not framed by record separators")
+
+	_, err = New([]byte("\x1E{}"))
+	assert.Error(t, err)
+}
+
+func TestSeqWriter(t *testing.T) {
+	b := &bytes.Buffer{}
+	sw := NewSeqWriter(b)
+	for _, in := range []string{`{"a": [1, 2]}`, "null", `"<a & b>"`} {
+		j, err := New([]byte(in))
+		assert.NoError(t, err)
+		assert.NoError(t, sw.Write(&j))
+	}
+	// unlike MarshalJSON, strings are not escaped for html
+	assert.Equal(t, "\x1E{\"a\":[1,2]}\n\x1Enull\n\x1E\"<a & b>\"\n", b.String())
+}
@@ -0,0 +1,203 @@
+package libjson
+
+import (
+	"fmt"
+	"go/format"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// initialisms are written in upper case in generated Go identifiers, as
+// suggested by the Go code review comments
+var initialisms = map[string]bool{
+	"API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true,
+	"EOF": true, "GUID": true, "HTML": true, "HTTP": true, "HTTPS": true,
+	"ID": true, "IP": true, "JSON": true, "OS": true, "SQL": true, "SSH": true,
+	"TCP": true, "TLS": true, "TTL": true, "UDP": true, "UI": true, "URI": true,
+	"URL": true, "UTF8": true, "UUID": true, "XML": true,
+}
+
+// structGen emits the Go type declarations of a shape
+type structGen struct {
+	buf strings.Builder
+	// type names already declared
+	names map[string]bool
+	// types to declare after the current one
+	pending []pendingType
+}
+
+type pendingType struct {
+	name string
+	s    *shape
+}
+
+// GenerateStructs infers the structure of docs like InferSchema and returns Go
+// type declarations with json tags for it, the top level type is called name.
+// Objects become struct types named after their parent type and key, members
+// missing in some objects are pointers tagged omitempty, as are members
+// holding null. Numbers are int64 if no observed literal has a fraction or an
+// exponent, float64 otherwise, thus 2.0 and 1e3 are float64. The literals are
+// only known for docs parsed with KeepNumberLiterals, otherwise numbers
+// without a fractional part are int64. Locations holding values of multiple
+// types or only null are typed any. The empty key can not be expressed as a
+// json tag and is skipped.
+func GenerateStructs(name string, docs ...*JSON) ([]byte, error) {
+	name = identifier(name)
+	g := structGen{names: map[string]bool{name: true}}
+	g.declare(name, inferShape(docs))
+	for len(g.pending) > 0 {
+		p := g.pending[0]
+		g.pending = g.pending[1:]
+		g.declare(p.name, p.s)
+	}
+	src, err := format.Source([]byte(g.buf.String()))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to format the generated code: %w", err)
+	}
+	return src, nil
+}
+
+// declare writes the declaration of the type name for s
+func (g *structGen) declare(name string, s *shape) {
+	if g.buf.Len() > 0 {
+		g.buf.WriteByte('\n')
+	}
+	fmt.Fprintf(&g.buf, "type %s ", name)
+	if s.kind() != "object" {
+		g.buf.WriteString(g.typ(name, "", s))
+		g.buf.WriteByte('\n')
+		return
+	}
+	g.buf.WriteString("struct {\n")
+	fields := map[string]bool{}
+	for _, key := range slices.Sorted(maps.Keys(s.properties)) {
+		if key == "" {
+			// encoding/json uses the field name for an empty tag name
+			continue
+		}
+		p := s.properties[key]
+		field := unique(fields, identifier(key))
+		typ := g.typ(name, field, p)
+		optional := p.count < s.objects
+		tag := key
+		if key == "-" {
+			// a tag of "-" omits the field
+			tag = "-,"
+		}
+		if (optional || p.nulls > 0) && typ != "any" && !strings.HasPrefix(typ, "[]") {
+			typ = "*" + typ
+		}
+		if optional || p.nulls > 0 {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(&g.buf, "\t%s %s `json:%s`\n", field, typ, strconv.Quote(tag))
+	}
+	g.buf.WriteString("}\n")
+}
+
+// typ returns the Go type of s, declaring struct types named parent+field
+// for objects
+func (g *structGen) typ(parent string, field string, s *shape) string {
+	switch s.kind() {
+	case "object":
+		name := unique(g.names, parent+field)
+		g.pending = append(g.pending, pendingType{name, s})
+		return name
+	case "array":
+		if s.items == nil || s.items.count == 0 {
+			return "[]any"
+		}
+		return "[]" + g.typ(parent, singular(field), s.items)
+	case "string":
+		return "string"
+	case "integer", "number":
+		if s.intLiterals == s.numbers {
+			return "int64"
+		}
+		return "float64"
+	case "boolean":
+		return "bool"
+	}
+	return "any"
+}
+
+// kind returns the type of all non null values of s, the empty string for
+// multiple types or only null
+func (s *shape) kind() string {
+	types := slices.DeleteFunc(s.types(), func(t string) bool { return t == "null" })
+	if len(types) != 1 {
+		return ""
+	}
+	return types[0]
+}
+
+// identifier converts the object key key to an exported Go identifier, such
+// as "user_id" to "UserID"
+func identifier(key string) string {
+	b := strings.Builder{}
+	for _, word := range words(key) {
+		if upper := strings.ToUpper(word); initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		r := []rune(word)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+	id := b.String()
+	if id == "" {
+		return "Field"
+	}
+	if !unicode.IsLetter([]rune(id)[0]) {
+		return "Field" + id
+	}
+	return id
+}
+
+// words splits s at characters not allowed in identifiers and at lower to
+// upper case transitions
+func words(s string) []string {
+	var r []string
+	cur := []rune{}
+	for _, c := range s {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			if len(cur) > 0 {
+				r = append(r, string(cur))
+				cur = cur[:0]
+			}
+			continue
+		}
+		if len(cur) > 0 && unicode.IsUpper(c) && unicode.IsLower(cur[len(cur)-1]) {
+			r = append(r, string(cur))
+			cur = cur[:0]
+		}
+		cur = append(cur, c)
+	}
+	if len(cur) > 0 {
+		r = append(r, string(cur))
+	}
+	return r
+}
+
+// singular strips the plural s of the field name of an array, to name its
+// elements
+func singular(field string) string {
+	if len(field) > 1 && strings.HasSuffix(field, "s") && !strings.HasSuffix(field, "ss") {
+		return field[:len(field)-1]
+	}
+	return field + "Elem"
+}
+
+// unique returns name, suffixed with a number if it is already used, and
+// marks the result as used
+func unique(used map[string]bool, name string) string {
+	r := name
+	for i := 2; used[r]; i++ {
+		r = name + strconv.Itoa(i)
+	}
+	used[r] = true
+	return r
+}
@@ -0,0 +1,120 @@
+package libjson
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateStructs(t *testing.T) {
+	docs := inferSamples(t,
+		`{"id": 1, "user_name": "a", "items": [{"sku": "x", "qty": 2, "price": 1.5}], "meta": {"url": "u"}, "tags": [], "misc": [1, "a"], "note": null}`,
+		`{"id": 2, "user_name": "b", "items": [{"sku": "y", "qty": 1, "price": 2}], "meta": null, "tags": ["a"], "misc": [], "1st": true}`,
+	)
+	src, err := GenerateStructs("order", docs...)
+	assert.NoError(t, err)
+	assert.Equal(t, "type Order struct {\n"+
+		"\tField1st *bool       `json:\"1st,omitempty\"`\n"+
+		"\tID       int64       `json:\"id\"`\n"+
+		"\tItems    []OrderItem `json:\"items\"`\n"+
+		"\tMeta     *OrderMeta  `json:\"meta,omitempty\"`\n"+
+		"\tMisc     []any       `json:\"misc\"`\n"+
+		"\tNote     any         `json:\"note,omitempty\"`\n"+
+		"\tTags     []string    `json:\"tags\"`\n"+
+		"\tUserName string      `json:\"user_name\"`\n"+
+		"}\n\n"+
+		"type OrderItem struct {\n"+
+		"\tPrice float64 `json:\"price\"`\n"+
+		"\tQty   int64   `json:\"qty\"`\n"+
+		"\tSku   string  `json:\"sku\"`\n"+
+		"}\n\n"+
+		"type OrderMeta struct {\n"+
+		"\tURL string `json:\"url\"`\n"+
+		"}\n", string(src))
+}
+
+func TestGenerateStructsTopLevel(t *testing.T) {
+	input := map[string]struct {
+		samples []string
+		want    string
+	}{
+		"empty":  {nil, "type T any\n"},
+		"number": {[]string{`1`, `1.5`}, "type T float64\n"},
+		"array":  {[]string{`[{"a": 1}]`}, "type T []TElem\n\ntype TElem struct {\n\tA int64 `json:\"a\"`\n}\n"},
+		"nested": {[]string{`[[true]]`}, "type T [][]bool\n"},
+	}
+	for name, i := range input {
+		t.Run(name, func(t *testing.T) {
+			src, err := GenerateStructs("T", inferSamples(t, i.samples...)...)
+			assert.NoError(t, err)
+			assert.Equal(t, i.want, string(src))
+		})
+	}
+}
+
+func TestGenerateStructsNames(t *testing.T) {
+	docs := inferSamples(t, `{"a": {}, "A": {}, "api-url": 1, "createdAt": 1, "ünï": 1, "": 1, "-": 1, "x": {"y": {}}}`)
+	src, err := GenerateStructs("t", docs...)
+	assert.NoError(t, err)
+	assert.Equal(t, "type T struct {\n"+
+		"\tField     int64 `json:\"-,\"`\n"+
+		"\tA         TA    `json:\"A\"`\n"+
+		"\tA2        TA2   `json:\"a\"`\n"+
+		"\tAPIURL    int64 `json:\"api-url\"`\n"+
+		"\tCreatedAt int64 `json:\"createdAt\"`\n"+
+		"\tX         TX    `json:\"x\"`\n"+
+		"\tÜnï       int64 `json:\"ünï\"`\n"+
+		"}\n\n"+
+		"type TA struct {\n}\n\n"+
+		"type TA2 struct {\n}\n\n"+
+		"type TX struct {\n"+
+		"\tY TXY `json:\"y\"`\n"+
+		"}\n\n"+
+		"type TXY struct {\n}\n", string(src))
+}
+
+// GeneratedPrices is the declaration generated for the sample in
+// TestGenerateStructsLiterals
+type GeneratedPrices struct {
+	Big   float64 `json:"big"`
+	Price float64 `json:"price"`
+	Qty   int64   `json:"qty"`
+}
+
+func TestGenerateStructsLiterals(t *testing.T) {
+	const sample = `{"price": 2.0, "big": 1e3, "qty": 2}`
+	parses := map[string]func() (JSON, error){
+		"New":       func() (JSON, error) { return New([]byte(sample), KeepNumberLiterals()) },
+		"NewReader": func() (JSON, error) { return NewReader(strings.NewReader(sample), KeepNumberLiterals()) },
+		"Parser":    func() (JSON, error) { return NewParser(KeepNumberLiterals()).Parse([]byte(sample)) },
+		"SeqReader": func() (JSON, error) { return NewSeqReader(strings.NewReader(sample), KeepNumberLiterals()).Next() },
+	}
+	for name, parse := range parses {
+		t.Run(name, func(t *testing.T) {
+			j, err := parse()
+			assert.NoError(t, err)
+			_, err = j.Position(".")
+			assert.Error(t, err, "positions are not recorded")
+			src, err := GenerateStructs("GeneratedPrices", &j)
+			assert.NoError(t, err)
+			assert.Equal(t, "type GeneratedPrices struct {\n"+
+				"\tBig   float64 `json:\"big\"`\n"+
+				"\tPrice float64 `json:\"price\"`\n"+
+				"\tQty   int64   `json:\"qty\"`\n"+
+				"}\n", string(src))
+		})
+	}
+
+	var p GeneratedPrices
+	d := json.NewDecoder(strings.NewReader(sample))
+	d.DisallowUnknownFields()
+	assert.NoError(t, d.Decode(&p))
+	assert.Equal(t, GeneratedPrices{Big: 1000, Price: 2, Qty: 2}, p)
+
+	// without the literals, the values decide
+	src, err := GenerateStructs("T", inferSamples(t, sample)...)
+	assert.NoError(t, err)
+	assert.Contains(t, string(src), "Price int64")
+}
@@ -5,6 +5,8 @@ type t_json int32
 
 type token struct {
 	Type t_json
+	// the string contains escape sequences, these are resolved via unescape
+	Escaped bool
 	// only populated for number and string
 	Start int
 	End   int
@@ -24,6 +26,7 @@ const (
 	t_right_braket               // ]
 	t_comma                      // ,
 	t_colon                      // :
+	t_ident                      // unquoted object keys of JSON5
 	t_eof                        // for any non structure characters outside of strings and numbers
 )
 
@@ -39,5 +42,6 @@ var tokennames = map[t_json]string{
 	t_right_braket: "]",
 	t_comma:        ",",
 	t_colon:        ":",
+	t_ident:        "identifier",
 	t_eof:          "EOF",
 }
@@ -0,0 +1,622 @@
+package libjson
+
+import (
+	"cmp"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// UnmarshalTypeError is returned by Unmarshal and (*JSON).Decode for a value
+// that can not be stored in the Go value at Path
+type UnmarshalTypeError struct {
+	// kind of the json value
+	Value Kind
+	Type  reflect.Type
+	Path  Path
+}
+
+func (e *UnmarshalTypeError) Error() string {
+	return fmt.Sprintf("Can not decode %s into Go value of type %s at %q", e.Value, e.Type, e.Path)
+}
+
+// Unmarshal parses data and stores the result in a value of type T, using
+// reflection, see (*JSON).Decode. If *T implements Unmarshaler, data is
+// passed to it instead, thus types generated by cmd/libjson-gen are decoded
+// without reflection. Strings are always copied, see CopyStrings.
+func Unmarshal[T any](data []byte, opts ...Option) (T, error) {
+	var v T
+	if u, ok := any(&v).(Unmarshaler); ok {
+		return v, u.UnmarshalLibJSON(data)
+	}
+	j, err := New(data, append(opts[:len(opts):len(opts)], CopyStrings())...)
+	if err != nil {
+		return v, err
+	}
+	d := decodeState{config: newConfig(opts)}
+	planFor(reflect.TypeFor[T]())(&d, reflect.ValueOf(&v).Elem(), j.obj)
+	return v, d.err
+}
+
+// Decode stores the value at path in dst, which has to be a non nil pointer,
+// following the semantics of encoding/json.Unmarshal:
+//
+//   - objects are decoded into structs, matching keys to the names of fields
+//     given by their `json:"name"` tag or to the field names, preferring an
+//     exact match over a case insensitive one. Unknown keys are ignored,
+//     unless DisallowUnknownFields is passed
+//   - arrays are decoded into slices and arrays, objects into maps with
+//     string, integer or encoding.TextUnmarshaler keys
+//   - null sets pointers, slices, maps and interfaces to nil and leaves all
+//     other values unchanged
+//   - values of types implementing Unmarshaler, json.Unmarshaler or, for
+//     strings, encoding.TextUnmarshaler decode themselves
+//   - decoding continues after a value not matching its Go type, the first
+//     of these errors is returned as *UnmarshalTypeError
+//
+// Unlike encoding/json, numbers are decoded from float64 values, thus
+// integers exceeding 2^53 lose precision. Strings alias the input of New,
+// see CopyStrings.
+func (j *JSON) Decode(path string, dst any, opts ...Option) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("Decode requires a non nil pointer, got %T", dst)
+	}
+	val, err := j.get(path)
+	if err != nil {
+		return err
+	}
+	d := decodeState{config: newConfig(opts)}
+	planFor(rv.Type().Elem())(&d, rv.Elem(), val)
+	return d.err
+}
+
+// DisallowUnknownFields makes Unmarshal and (*JSON).Decode fail for object
+// keys not matching any field of the struct decoded into
+func DisallowUnknownFields() Option {
+	return func(c *config) {
+		c.disallowUnknown = true
+	}
+}
+
+// decodeState is the state of a single call to Unmarshal or Decode
+type decodeState struct {
+	config
+	// location of the value currently decoded, converted to a Path only
+	// for errors, since boxing every key into an any allocates
+	path []pathElem
+	// first error, decoding continues after errors as for encoding/json
+	err error
+}
+
+// pathElem is an array index or, if index is -1, an object key
+type pathElem struct {
+	key   string
+	index int
+}
+
+func (d *decodeState) push(key string, index int) {
+	d.path = append(d.path, pathElem{key, index})
+}
+
+func (d *decodeState) pop() {
+	d.path = d.path[:len(d.path)-1]
+}
+
+// location returns the Path of the value currently decoded
+func (d *decodeState) location() Path {
+	p := make(Path, len(d.path))
+	for i, e := range d.path {
+		if e.index == -1 {
+			p[i] = e.key
+		} else {
+			p[i] = e.index
+		}
+	}
+	return p
+}
+
+func (d *decodeState) save(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+}
+
+func (d *decodeState) mismatch(val any, t reflect.Type) {
+	if d.err == nil {
+		d.err = &UnmarshalTypeError{Value: Value{v: val}.Kind(), Type: t, Path: d.location()}
+	}
+}
+
+// decodeFunc stores val in v, which is settable
+type decodeFunc func(d *decodeState, v reflect.Value, val any)
+
+// plans caches the decodeFunc of every type, see planFor
+var plans sync.Map
+
+// planFor returns the cached decodeFunc for t, creating it if necessary
+func planFor(t reflect.Type) decodeFunc {
+	if f, ok := plans.Load(t); ok {
+		return f.(decodeFunc)
+	}
+	// recursive types refer to their own plan while it is created, thus an
+	// indirection waiting for the plan is stored first
+	var wg sync.WaitGroup
+	var f decodeFunc
+	wg.Add(1)
+	fi, loaded := plans.LoadOrStore(t, decodeFunc(func(d *decodeState, v reflect.Value, val any) {
+		wg.Wait()
+		f(d, v, val)
+	}))
+	if loaded {
+		return fi.(decodeFunc)
+	}
+	f = newPlan(t)
+	wg.Done()
+	plans.Store(t, f)
+	return f
+}
+
+var (
+	unmarshalerType     = reflect.TypeFor[Unmarshaler]()
+	jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+func newPlan(t reflect.Type) decodeFunc {
+	if t.Kind() != reflect.Pointer {
+		pt := reflect.PointerTo(t)
+		if pt.Implements(unmarshalerType) || pt.Implements(jsonUnmarshalerType) {
+			return decodeUnmarshaler
+		}
+		if pt.Implements(textUnmarshalerType) {
+			return decodeText
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return func(d *decodeState, v reflect.Value, val any) {
+			if b, ok := val.(bool); ok {
+				v.SetBool(b)
+			} else if val != nil {
+				d.mismatch(val, v.Type())
+			}
+		}
+	case reflect.String:
+		return func(d *decodeState, v reflect.Value, val any) {
+			if s, ok := val.(string); ok {
+				v.SetString(s)
+			} else if val != nil {
+				d.mismatch(val, v.Type())
+			}
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return func(d *decodeState, v reflect.Value, val any) {
+			if f, ok := val.(float64); ok {
+				if !setNumber(v, f) {
+					d.mismatch(val, v.Type())
+				}
+			} else if val != nil {
+				d.mismatch(val, v.Type())
+			}
+		}
+	case reflect.Interface:
+		return decodeInterface
+	case reflect.Pointer:
+		elem := planFor(t.Elem())
+		return func(d *decodeState, v reflect.Value, val any) {
+			if val == nil {
+				v.SetZero()
+				return
+			}
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			elem(d, v.Elem(), val)
+		}
+	case reflect.Slice:
+		return newSlicePlan(t)
+	case reflect.Array:
+		elem := planFor(t.Elem())
+		return func(d *decodeState, v reflect.Value, val any) {
+			a, ok := val.([]any)
+			if !ok {
+				if val != nil {
+					d.mismatch(val, v.Type())
+				}
+				return
+			}
+			for i := range v.Len() {
+				if i >= len(a) {
+					v.Index(i).SetZero()
+					continue
+				}
+				d.push("", i)
+				elem(d, v.Index(i), a[i])
+				d.pop()
+			}
+		}
+	case reflect.Map:
+		return newMapPlan(t)
+	case reflect.Struct:
+		return newStructPlan(t)
+	default:
+		return func(d *decodeState, v reflect.Value, val any) {
+			d.mismatch(val, v.Type())
+		}
+	}
+}
+
+// setNumber stores f in the number v, reports false if it does not fit
+func setNumber(v reflect.Value, f float64) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 || v.OverflowInt(int64(f)) {
+			return false
+		}
+		v.SetInt(int64(f))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if f != math.Trunc(f) || f < 0 || f >= math.MaxUint64 || v.OverflowUint(uint64(f)) {
+			return false
+		}
+		v.SetUint(uint64(f))
+	default:
+		if v.OverflowFloat(f) {
+			return false
+		}
+		v.SetFloat(f)
+	}
+	return true
+}
+
+// decodeUnmarshaler passes the encoded val to the Unmarshaler implemented by
+// the pointer to v. val is encoded as by (*JSON).Append, thus strings are not
+// escaped for html and non-finite numbers, see AllowNonFinite, are passed on
+// as their literals.
+func decodeUnmarshaler(d *decodeState, v reflect.Value, val any) {
+	c := config{nonFinite: NonFiniteLiteral}
+	data, err := appendValue(nil, val, &c)
+	if err != nil {
+		d.save(err)
+		return
+	}
+	switch u := v.Addr().Interface().(type) {
+	case Unmarshaler:
+		err = u.UnmarshalLibJSON(data)
+	case json.Unmarshaler:
+		err = u.UnmarshalJSON(data)
+	}
+	d.save(err)
+}
+
+func decodeText(d *decodeState, v reflect.Value, val any) {
+	s, ok := val.(string)
+	if !ok {
+		if val != nil {
+			d.mismatch(val, v.Type())
+		}
+		return
+	}
+	d.save(v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)))
+}
+
+// decodeInterface stores val as is in empty interfaces, non empty interfaces
+// holding a pointer are decoded into
+func decodeInterface(d *decodeState, v reflect.Value, val any) {
+	if v.NumMethod() == 0 {
+		if val == nil {
+			v.SetZero()
+		} else {
+			v.Set(reflect.ValueOf(val))
+		}
+		return
+	}
+	if val == nil {
+		v.SetZero()
+		return
+	}
+	if v.IsNil() || v.Elem().Kind() != reflect.Pointer || v.Elem().IsNil() {
+		d.mismatch(val, v.Type())
+		return
+	}
+	e := v.Elem()
+	planFor(e.Type())(d, e, val)
+}
+
+func newSlicePlan(t reflect.Type) decodeFunc {
+	elem := planFor(t.Elem())
+	bytes := t.Elem().Kind() == reflect.Uint8
+	return func(d *decodeState, v reflect.Value, val any) {
+		if s, ok := val.(string); ok && bytes {
+			// []byte is encoded as base64, as for encoding/json
+			b, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				d.save(err)
+				return
+			}
+			v.SetBytes(b)
+			return
+		}
+		a, ok := val.([]any)
+		if !ok {
+			if val == nil {
+				v.SetZero()
+			} else {
+				d.mismatch(val, v.Type())
+			}
+			return
+		}
+		s := reflect.MakeSlice(v.Type(), len(a), len(a))
+		for i, e := range a {
+			d.push("", i)
+			elem(d, s.Index(i), e)
+			d.pop()
+		}
+		v.Set(s)
+	}
+}
+
+func newMapPlan(t reflect.Type) decodeFunc {
+	var key func(k string) (reflect.Value, error)
+	kt := t.Key()
+	switch {
+	case reflect.PointerTo(kt).Implements(textUnmarshalerType):
+		key = func(k string) (reflect.Value, error) {
+			kv := reflect.New(kt)
+			err := kv.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(k))
+			return kv.Elem(), err
+		}
+	case kt.Kind() == reflect.String:
+		key = func(k string) (reflect.Value, error) {
+			return reflect.ValueOf(k).Convert(kt), nil
+		}
+	case kt.Kind() >= reflect.Int && kt.Kind() <= reflect.Int64:
+		key = func(k string) (reflect.Value, error) {
+			n, err := strconv.ParseInt(k, 10, kt.Bits())
+			return reflect.ValueOf(n).Convert(kt), err
+		}
+	case kt.Kind() >= reflect.Uint && kt.Kind() <= reflect.Uintptr:
+		key = func(k string) (reflect.Value, error) {
+			n, err := strconv.ParseUint(k, 10, kt.Bits())
+			return reflect.ValueOf(n).Convert(kt), err
+		}
+	default:
+		return func(d *decodeState, v reflect.Value, val any) {
+			d.mismatch(val, v.Type())
+		}
+	}
+
+	elem := planFor(t.Elem())
+	return func(d *decodeState, v reflect.Value, val any) {
+		m, ok := val.(map[string]any)
+		if !ok {
+			if val == nil {
+				v.SetZero()
+			} else {
+				d.mismatch(val, v.Type())
+			}
+			return
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMapWithSize(v.Type(), len(m)))
+		}
+		for k, e := range m {
+			d.push(k, -1)
+			kv, err := key(k)
+			if err != nil {
+				d.save(fmt.Errorf("Can not decode key %q into Go value of type %s at %q: %w", k, kt, d.location(), err))
+			} else {
+				ev := reflect.New(t.Elem()).Elem()
+				elem(d, ev, e)
+				v.SetMapIndex(kv, ev)
+			}
+			d.pop()
+		}
+	}
+}
+
+// structField is a field of a struct or of its embedded structs, that is
+// decoded from an object member
+type structField struct {
+	name string
+	// index sequence, see reflect.Value.FieldByIndex
+	index  []int
+	tagged bool
+	decode decodeFunc
+}
+
+func newStructPlan(t reflect.Type) decodeFunc {
+	fields := typeFields(t)
+	exact := make(map[string]*structField, len(fields))
+	for i := range fields {
+		exact[fields[i].name] = &fields[i]
+	}
+	lookup := func(key string) *structField {
+		if f, ok := exact[key]; ok {
+			return f
+		}
+		for i := range fields {
+			if strings.EqualFold(fields[i].name, key) {
+				return &fields[i]
+			}
+		}
+		return nil
+	}
+
+	return func(d *decodeState, v reflect.Value, val any) {
+		m, ok := val.(map[string]any)
+		if !ok {
+			if val != nil {
+				d.mismatch(val, v.Type())
+			}
+			return
+		}
+		for k, e := range m {
+			f := lookup(k)
+			if f == nil {
+				if d.disallowUnknown {
+					d.save(fmt.Errorf("Unknown field %q for Go value of type %s at %q", k, v.Type(), d.location()))
+				}
+				continue
+			}
+			d.push(k, -1)
+			if fv, err := fieldByIndex(v, f.index); err != nil {
+				d.save(err)
+			} else {
+				f.decode(d, fv, e)
+			}
+			d.pop()
+		}
+	}
+}
+
+// fieldByIndex is reflect.Value.FieldByIndex, allocating nil pointers to
+// embedded structs
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return v, fmt.Errorf("Can not set embedded pointer to unexported struct %s", v.Type().Elem())
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, nil
+}
+
+// typeFields returns the fields of t decoded from object members, including
+// the fields promoted from embedded structs. Of multiple fields with the same
+// name the least nested one is used, if there are multiple on this level,
+// the single tagged one. Otherwise the name is ignored, as for encoding/json.
+func typeFields(t reflect.Type) []structField {
+	type embedded struct {
+		t     reflect.Type
+		index []int
+	}
+	var fields []structField
+	next := []embedded{{t: t}}
+	visited := map[reflect.Type]bool{}
+	for len(next) > 0 {
+		current := next
+		next = nil
+		for _, e := range current {
+			if visited[e.t] {
+				continue
+			}
+			visited[e.t] = true
+			for i := range e.t.NumField() {
+				sf := e.t.Field(i)
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if sf.Anonymous {
+					if !sf.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !sf.IsExported() {
+					continue
+				}
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts, _ := strings.Cut(tag, ",")
+				index := append(slices.Clone(e.index), i)
+				if name == "" && sf.Anonymous && ft.Kind() == reflect.Struct {
+					next = append(next, embedded{t: ft, index: index})
+					continue
+				}
+				f := structField{name: name, index: index, tagged: name != ""}
+				if name == "" {
+					f.name = sf.Name
+				}
+				f.decode = planFor(sf.Type)
+				if slices.Contains(strings.Split(opts, ","), "string") {
+					switch ft.Kind() {
+					case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+						reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+						reflect.Float32, reflect.Float64:
+						f.decode = quoted(f.decode)
+					}
+				}
+				fields = append(fields, f)
+			}
+		}
+	}
+
+	slices.SortStableFunc(fields, func(a, b structField) int {
+		return cmp.Or(
+			strings.Compare(a.name, b.name),
+			cmp.Compare(len(a.index), len(b.index)),
+		)
+	})
+	dominant := fields[:0]
+	for i := 0; i < len(fields); {
+		j := i + 1
+		for j < len(fields) && fields[j].name == fields[i].name && len(fields[j].index) == len(fields[i].index) {
+			j++
+		}
+		level := fields[i:j]
+		if len(level) == 1 {
+			dominant = append(dominant, level[0])
+		} else if tagged := slices.DeleteFunc(slices.Clone(level), func(f structField) bool { return !f.tagged }); len(tagged) == 1 {
+			dominant = append(dominant, tagged[0])
+		}
+		// skip the more nested fields with the same name
+		for j < len(fields) && fields[j].name == fields[i].name {
+			j++
+		}
+		i = j
+	}
+	slices.SortFunc(dominant, func(a, b structField) int {
+		return slices.Compare(a.index, b.index)
+	})
+	return dominant
+}
+
+// quoted wraps decode for fields tagged with the string option, their
+// numbers and bools are encoded as strings
+func quoted(decode decodeFunc) decodeFunc {
+	return func(d *decodeState, v reflect.Value, val any) {
+		s, ok := val.(string)
+		if !ok {
+			if val != nil {
+				d.mismatch(val, v.Type())
+			}
+			return
+		}
+		var inner any
+		switch s {
+		case "null":
+			inner = nil
+		case "true":
+			inner = true
+		case "false":
+			inner = false
+		default:
+			f, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				d.save(fmt.Errorf("Invalid string encoded value %q at %q: %w", s, d.location(), errors.Unwrap(err)))
+				return
+			}
+			inner = f
+		}
+		decode(d, v, inner)
+	}
+}
@@ -0,0 +1,234 @@
+package libjson
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type UnmarshalBase struct {
+	ID     int `json:"id"`
+	Hidden string
+}
+
+type unmarshalText string
+
+func (t *unmarshalText) UnmarshalText(b []byte) error {
+	*t = unmarshalText(strings.ToUpper(string(b)))
+	return nil
+}
+
+type unmarshalNode struct {
+	Value    int              `json:"value"`
+	Children []*unmarshalNode `json:"children"`
+}
+
+type unmarshalStruct struct {
+	*UnmarshalBase
+	Hidden   int                        `json:"hidden"`
+	Name     string                     `json:"name"`
+	Age      uint8                      `json:"age,omitempty"`
+	Count    int64                      `json:"count,string"`
+	Ratio    float32                    `json:"ratio"`
+	Ok       bool                       `json:"ok"`
+	Ptr      *string                    `json:"ptr"`
+	Tags     []string                   `json:"tags"`
+	Pair     [2]int                     `json:"pair"`
+	Labels   map[string]int             `json:"labels"`
+	ByID     map[int]string             `json:"by_id"`
+	Any      any                        `json:"any"`
+	Raw      json.RawMessage            `json:"raw"`
+	Time     time.Time                  `json:"time"`
+	Text     unmarshalText              `json:"text"`
+	TextKeys map[unmarshalText]bool     `json:"text_keys"`
+	Bytes    []byte                     `json:"bytes"`
+	Tree     unmarshalNode              `json:"tree"`
+	Nested   map[string][]UnmarshalBase `json:"nested"`
+	Skipped  string                     `json:"-"`
+	private  string
+}
+
+func TestUnmarshal(t *testing.T) {
+	input := `{
+		"id": 7,
+		"Hidden": "base",
+		"hidden": 3,
+		"NAME": "case insensitive",
+		"age": 42,
+		"count": "12",
+		"ratio": 0.5,
+		"ok": true,
+		"ptr": "pointer",
+		"tags": ["a", "b"],
+		"pair": [1, 2, 3],
+		"labels": {"x": 1},
+		"by_id": {"1": "one"},
+		"any": {"k": [1, null]},
+		"raw": {"a": [1]},
+		"time": "2024-01-02T03:04:05Z",
+		"text": "upper",
+		"text_keys": {"key": true},
+		"bytes": "aGVsbG8=",
+		"tree": {"value": 1, "children": [{"value": 2, "children": null}]},
+		"nested": {"n": [{"id": 1}]},
+		"Skipped": "no",
+		"private": "no",
+		"unknown": 1
+	}`
+	got, err := Unmarshal[unmarshalStruct]([]byte(input))
+	assert.NoError(t, err)
+
+	var want unmarshalStruct
+	assert.NoError(t, json.Unmarshal([]byte(input), &want))
+	// encoding/json keeps the raw input, thus compare its decoded value
+	var raw, wantRaw any
+	assert.NoError(t, json.Unmarshal(got.Raw, &raw))
+	assert.NoError(t, json.Unmarshal(want.Raw, &wantRaw))
+	assert.Equal(t, wantRaw, raw)
+	got.Raw, want.Raw = nil, nil
+	assert.Equal(t, want, got)
+	assert.Equal(t, "case insensitive", got.Name)
+	assert.Equal(t, 7, got.UnmarshalBase.ID)
+	assert.Equal(t, unmarshalText("UPPER"), got.Text)
+}
+
+func TestUnmarshalPrimitives(t *testing.T) {
+	s, err := Unmarshal[string]([]byte(`"str"`))
+	assert.NoError(t, err)
+	assert.Equal(t, "str", s)
+	s, err = Unmarshal[string]([]byte(`"a\"b\nc"`))
+	assert.NoError(t, err)
+	assert.Equal(t, "a\"b\nc", s)
+	i, err := Unmarshal[[]int]([]byte(`[1, 2]`))
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, i)
+	p, err := Unmarshal[*float64]([]byte(`null`))
+	assert.NoError(t, err)
+	assert.Nil(t, p)
+	m, err := Unmarshal[map[string]any]([]byte(`{"a": [true]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"a": []any{true}}, m)
+}
+
+func TestUnmarshalCopiesStrings(t *testing.T) {
+	in := []byte(`{"name": "value"}`)
+	got, err := Unmarshal[unmarshalStruct](in)
+	assert.NoError(t, err)
+	copy(in, strings.Repeat("x", len(in)))
+	assert.Equal(t, "value", got.Name)
+}
+
+func TestUnmarshalFail(t *testing.T) {
+	input := []string{
+		`{"name": 1}`,
+		`{"age": 256}`,
+		`{"age": -1}`,
+		`{"age": 1.5}`,
+		`{"count": 12}`,
+		`{"count": "x"}`,
+		`{"tags": {}}`,
+		`{"by_id": {"x": "one"}}`,
+		`{"time": "yesterday"}`,
+		`{"bytes": "!"}`,
+		`{"tree": {"children": [{"value": "x"}]}}`,
+		`[]`,
+		`{`,
+	}
+	for _, i := range input {
+		t.Run(i, func(t *testing.T) {
+			_, err := Unmarshal[unmarshalStruct]([]byte(i))
+			assert.Error(t, err)
+			var want unmarshalStruct
+			assert.Error(t, json.Unmarshal([]byte(i), &want))
+		})
+	}
+}
+
+func TestUnmarshalTypeError(t *testing.T) {
+	got, err := Unmarshal[unmarshalStruct]([]byte(`{"tree": {"children": [{"value": "x"}]}, "name": "still decoded"}`))
+	var typeErr *UnmarshalTypeError
+	assert.True(t, errors.As(err, &typeErr))
+	assert.Equal(t, KindString, typeErr.Value)
+	assert.Equal(t, ".tree.children.0.value", typeErr.Path.String())
+	assert.Equal(t, "still decoded", got.Name)
+}
+
+func TestUnmarshalDisallowUnknownFields(t *testing.T) {
+	_, err := Unmarshal[unmarshalStruct]([]byte(`{"name": "a", "unknown": 1}`), DisallowUnknownFields())
+	assert.Error(t, err)
+	_, err = Unmarshal[unmarshalStruct]([]byte(`{"name": "a", "Age": 1}`), DisallowUnknownFields())
+	assert.NoError(t, err)
+}
+
+type unmarshalLibJSON struct {
+	called bool
+	data   string
+}
+
+func (u *unmarshalLibJSON) UnmarshalLibJSON(data []byte) error {
+	u.called = true
+	u.data = string(data)
+	return nil
+}
+
+func TestUnmarshalUnmarshaler(t *testing.T) {
+	u, err := Unmarshal[unmarshalLibJSON]([]byte(` {"a": 1} `))
+	assert.NoError(t, err)
+	assert.True(t, u.called)
+	assert.Equal(t, ` {"a": 1} `, u.data)
+
+	s, err := Unmarshal[struct{ U unmarshalLibJSON }]([]byte(`{"U": {"a": 1}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a":1}`, s.U.data)
+
+	// the subtree is encoded as this package does, not as encoding/json
+	s, err = Unmarshal[struct{ U unmarshalLibJSON }]([]byte(`{"U": {"s": "<a & b>", "n": [NaN, -Infinity]}}`), AllowNonFinite())
+	assert.NoError(t, err)
+	assert.Equal(t, `{"n":[NaN,-Infinity],"s":"<a & b>"}`, s.U.data)
+}
+
+func TestJSONDecode(t *testing.T) {
+	j, err := New([]byte(`{"users": [{"name": "a", "id": 1}, {"name": "b", "id": 2}]}`))
+	assert.NoError(t, err)
+	var users []unmarshalStruct
+	assert.NoError(t, j.Decode(".users", &users))
+	assert.Len(t, users, 2)
+	assert.Equal(t, "b", users[1].Name)
+	assert.Equal(t, 2, users[1].ID)
+
+	var name string
+	assert.NoError(t, j.Decode(".users.0.name", &name))
+	assert.Equal(t, "a", name)
+
+	assert.Error(t, j.Decode(".users", users))
+	assert.Error(t, j.Decode(".users", nil))
+	assert.Error(t, j.Decode(".users.0", &name))
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	type user struct {
+		ID   int      `json:"id"`
+		Name string   `json:"name"`
+		Tags []string `json:"tags"`
+	}
+	input := []byte(`[` + strings.Repeat(`{"id": 1, "name": "name", "tags": ["a", "b"]},`, 999) + `{"id": 1, "name": "name", "tags": []}]`)
+	b.Run("libjson", func(b *testing.B) {
+		for range b.N {
+			if _, err := Unmarshal[[]user](input); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("encoding/json", func(b *testing.B) {
+		for range b.N {
+			var u []user
+			if err := json.Unmarshal(input, &u); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
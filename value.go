@@ -0,0 +1,201 @@
+package libjson
+
+import (
+	"fmt"
+	"math"
+)
+
+// Kind represents the json type of a Value
+type Kind uint8
+
+const (
+	KindInvalid Kind = iota // result of a failed navigation, see Value.Err
+	KindNull
+	KindBool
+	KindNumber
+	KindString
+	KindArray
+	KindObject
+)
+
+var kindnames = map[Kind]string{
+	KindInvalid: "invalid",
+	KindNull:    "null",
+	KindBool:    "bool",
+	KindNumber:  "number",
+	KindString:  "string",
+	KindArray:   "array",
+	KindObject:  "object",
+}
+
+func (k Kind) String() string {
+	return kindnames[k]
+}
+
+// Value wraps a node of the tree produced by the parser without copying it.
+// Navigating via Index, Key and Path never fails loudly, instead the first
+// error is kept and returned by Err and every accessor, thus a chain of calls
+// only requires a single error check at its end:
+//
+//	name, err := doc.Root().Path(".users").Index(0).Key("name").AsString()
+type Value struct {
+	v   any
+	err error
+	// set while iterating via (*JSON).Walk, see Value.SkipChildren
+	skip *bool
+}
+
+// Root returns the top level value of j
+func (j *JSON) Root() Value {
+	return Value{v: j.obj}
+}
+
+// Err returns the first error encountered while navigating to v
+func (v Value) Err() error {
+	return v.err
+}
+
+// Interface returns the underlying value, this is either map[string]any,
+// []any, string, float64, bool or nil
+func (v Value) Interface() any {
+	return v.v
+}
+
+func (v Value) Kind() Kind {
+	if v.err != nil {
+		return KindInvalid
+	}
+	switch v.v.(type) {
+	case nil:
+		return KindNull
+	case bool:
+		return KindBool
+	case float64:
+		return KindNumber
+	case string:
+		return KindString
+	case []any:
+		return KindArray
+	case map[string]any:
+		return KindObject
+	default:
+		return KindInvalid
+	}
+}
+
+// Len returns the amount of elements of an array, members of an object or
+// bytes of a string, zero for all other kinds
+func (v Value) Len() int {
+	if v.err != nil {
+		return 0
+	}
+	switch t := v.v.(type) {
+	case string:
+		return len(t)
+	case []any:
+		return len(t)
+	case map[string]any:
+		return len(t)
+	default:
+		return 0
+	}
+}
+
+func (v Value) AsString() (string, error) {
+	if v.err != nil {
+		return "", v.err
+	}
+	if s, ok := v.v.(string); ok {
+		return s, nil
+	}
+	return "", fmt.Errorf("Expected value of kind string, got kind %s", v.Kind())
+}
+
+func (v Value) AsBool() (bool, error) {
+	if v.err != nil {
+		return false, v.err
+	}
+	if b, ok := v.v.(bool); ok {
+		return b, nil
+	}
+	return false, fmt.Errorf("Expected value of kind bool, got kind %s", v.Kind())
+}
+
+func (v Value) AsFloat() (float64, error) {
+	if v.err != nil {
+		return 0, v.err
+	}
+	if f, ok := v.v.(float64); ok {
+		return f, nil
+	}
+	return 0, fmt.Errorf("Expected value of kind number, got kind %s", v.Kind())
+}
+
+// AsInt returns v as an int, if v is a number without a fractional part
+// fitting into an int
+func (v Value) AsInt() (int, error) {
+	f, err := v.AsFloat()
+	if err != nil {
+		return 0, err
+	}
+	if f != math.Trunc(f) || f < math.MinInt || f >= math.MaxInt {
+		return 0, fmt.Errorf("Number %v can not be represented as an int", f)
+	}
+	return int(f), nil
+}
+
+// Index returns the i-th element of the array v
+func (v Value) Index(i int) Value {
+	if v.err != nil {
+		return v
+	}
+	a, ok := v.v.([]any)
+	if !ok {
+		return Value{err: fmt.Errorf("Can not index into %s with %d", v.Kind(), i)}
+	}
+	if i < 0 || i >= len(a) {
+		return Value{err: fmt.Errorf("Index %d out of range for array of length %d", i, len(a))}
+	}
+	return Value{v: a[i]}
+}
+
+// Key returns the value of the member k of the object v
+func (v Value) Key(k string) Value {
+	if v.err != nil {
+		return v
+	}
+	m, ok := v.v.(map[string]any)
+	if !ok {
+		return Value{err: fmt.Errorf("Can not index into %s with %q", v.Kind(), k)}
+	}
+	val, ok := m[k]
+	if !ok {
+		return Value{err: fmt.Errorf("Key %q not found in object", k)}
+	}
+	return Value{v: val}
+}
+
+// Path navigates from v using the same syntax as Get, for instance
+// ".hello.world.0"
+func (v Value) Path(path string) Value {
+	if v.err != nil {
+		return v
+	}
+	f, err := parsePath(path)
+	if err != nil {
+		return Value{err: err}
+	}
+	val, err := f(v.v)
+	if err != nil {
+		return Value{err: err}
+	}
+	return Value{v: val}
+}
+
+// SkipChildren prevents (*JSON).Walk from descending into v, it has no effect
+// outside of Walk
+func (v Value) SkipChildren() {
+	if v.skip != nil {
+		*v.skip = true
+	}
+}
@@ -0,0 +1,82 @@
+package libjson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValueKind(t *testing.T) {
+	input := []struct {
+		inp  string
+		kind Kind
+	}{
+		{"null", KindNull},
+		{"true", KindBool},
+		{"12", KindNumber},
+		{`"str"`, KindString},
+		{"[1,2]", KindArray},
+		{`{"a": 1}`, KindObject},
+	}
+	for _, i := range input {
+		t.Run(i.inp, func(t *testing.T) {
+			obj, err := New([]byte(i.inp))
+			assert.NoError(t, err)
+			assert.Equal(t, i.kind, obj.Root().Kind())
+		})
+	}
+}
+
+func TestValueNavigation(t *testing.T) {
+	obj, err := New([]byte(`{ "users": [{"name": "xnacly", "age": 25, "admin": true}], "pi": 3.14 }`))
+	assert.NoError(t, err)
+
+	root := obj.Root()
+	assert.Equal(t, 2, root.Len())
+	assert.Equal(t, 1, root.Key("users").Len())
+
+	name, err := root.Key("users").Index(0).Key("name").AsString()
+	assert.NoError(t, err)
+	assert.Equal(t, "xnacly", name)
+
+	age, err := root.Path(".users.0.age").AsInt()
+	assert.NoError(t, err)
+	assert.Equal(t, 25, age)
+
+	admin, err := root.Path(".users.0").Key("admin").AsBool()
+	assert.NoError(t, err)
+	assert.True(t, admin)
+
+	pi, err := root.Key("pi").AsFloat()
+	assert.NoError(t, err)
+	assert.Equal(t, 3.14, pi)
+}
+
+func TestValueErr(t *testing.T) {
+	obj, err := New([]byte(`{ "users": [{"name": "xnacly"}], "pi": 3.14 }`))
+	assert.NoError(t, err)
+	root := obj.Root()
+
+	input := []Value{
+		root.Key("missing").Index(0).Key("name"),
+		root.Key("users").Index(5).Key("name"),
+		root.Key("users").Key("name"),
+		root.Key("pi").Index(0),
+		root.Path(""),
+		root.Path(".users.5"),
+		root.Path(".users.0.missing"),
+		root.Path(".missing"),
+	}
+	for _, v := range input {
+		assert.Error(t, v.Err())
+		assert.Equal(t, KindInvalid, v.Kind())
+		assert.Equal(t, 0, v.Len())
+		_, err := v.AsString()
+		assert.Error(t, err)
+	}
+
+	_, err = root.Key("pi").AsInt()
+	assert.Error(t, err)
+	_, err = root.Key("pi").AsString()
+	assert.Error(t, err)
+}
@@ -0,0 +1,112 @@
+package libjson
+
+import (
+	"errors"
+	"io"
+)
+
+var (
+	// SkipValue returned from a Handler callback skips the current object or
+	// array, or the value of the current key, without invoking any further
+	// callbacks for it
+	SkipValue = errors.New("skip this value")
+	// SkipAll returned from a Handler callback stops Walk without an error
+	SkipAll = errors.New("skip all remaining values")
+)
+
+// Handler receives events for each token read by Walk, returning any error
+// other than SkipValue or SkipAll aborts Walk with that error
+type Handler interface {
+	StartObject() error
+	Key(key string) error
+	EndObject() error
+	StartArray() error
+	EndArray() error
+	String(s string) error
+	Number(f float64) error
+	Bool(b bool) error
+	Null() error
+}
+
+// NopHandler implements Handler by ignoring all events, embed it to only
+// implement the callbacks of interest
+type NopHandler struct{}
+
+func (NopHandler) StartObject() error   { return nil }
+func (NopHandler) Key(string) error     { return nil }
+func (NopHandler) EndObject() error     { return nil }
+func (NopHandler) StartArray() error    { return nil }
+func (NopHandler) EndArray() error      { return nil }
+func (NopHandler) String(string) error  { return nil }
+func (NopHandler) Number(float64) error { return nil }
+func (NopHandler) Bool(bool) error      { return nil }
+func (NopHandler) Null() error          { return nil }
+
+// Walk reads all json values from r and invokes the callbacks of h for each
+// token, without building the tree of the values
+func Walk(r io.Reader, h Handler) error {
+	d := NewDecoder(r)
+	for {
+		t, err := d.Token()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		switch t.Kind {
+		case TokenObjectStart:
+			err = h.StartObject()
+			if err == SkipValue {
+				err = skipRest(d)
+			}
+		case TokenObjectEnd:
+			err = h.EndObject()
+		case TokenArrayStart:
+			err = h.StartArray()
+			if err == SkipValue {
+				err = skipRest(d)
+			}
+		case TokenArrayEnd:
+			err = h.EndArray()
+		case TokenKey:
+			err = h.Key(t.Value.(string))
+			if err == SkipValue {
+				err = d.Skip()
+			}
+		case TokenString:
+			err = h.String(t.Value.(string))
+		case TokenNumber:
+			err = h.Number(t.Value.(float64))
+		case TokenBool:
+			err = h.Bool(t.Value.(bool))
+		case TokenNull:
+			err = h.Null()
+		}
+
+		if err == SkipAll {
+			return nil
+		} else if err != nil && err != SkipValue {
+			return err
+		}
+	}
+}
+
+// skipRest consumes all tokens up to and including the end of the container
+// d is currently in
+func skipRest(d *Decoder) error {
+	depth := 1
+	for depth > 0 {
+		t, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t.Kind {
+		case TokenObjectStart, TokenArrayStart:
+			depth++
+		case TokenObjectEnd, TokenArrayEnd:
+			depth--
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package libjson
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder records all events, returning the signal configured for an event
+type recorder struct {
+	events  []string
+	signals map[string]error
+}
+
+func (r *recorder) event(e string) error {
+	r.events = append(r.events, e)
+	return r.signals[e]
+}
+
+func (r *recorder) StartObject() error     { return r.event("{") }
+func (r *recorder) Key(k string) error     { return r.event("key:" + k) }
+func (r *recorder) EndObject() error       { return r.event("}") }
+func (r *recorder) StartArray() error      { return r.event("[") }
+func (r *recorder) EndArray() error        { return r.event("]") }
+func (r *recorder) String(s string) error  { return r.event("string:" + s) }
+func (r *recorder) Number(f float64) error { return r.event(fmt.Sprint("number:", f)) }
+func (r *recorder) Bool(b bool) error      { return r.event(fmt.Sprint("bool:", b)) }
+func (r *recorder) Null() error            { return r.event("null") }
+
+func TestWalk(t *testing.T) {
+	input := `{"id": "a", "tags": ["x", 1, true, null], "nested": {"deep": [1, 2]}, "last": false}`
+	input2 := `1 2 3`
+	errFail := errors.New("failed")
+	tests := []struct {
+		name    string
+		signals map[string]error
+		wanted  []string
+		err     error
+	}{
+		{"all", nil, []string{
+			"{", "key:id", "string:a",
+			"key:tags", "[", "string:x", "number:1", "bool:true", "null", "]",
+			"key:nested", "{", "key:deep", "[", "number:1", "number:2", "]", "}",
+			"key:last", "bool:false", "}",
+		}, nil},
+		{"skip key", map[string]error{"key:tags": SkipValue, "key:nested": SkipValue}, []string{
+			"{", "key:id", "string:a", "key:tags", "key:nested", "key:last", "bool:false", "}",
+		}, nil},
+		{"skip container", map[string]error{"[": SkipValue}, []string{
+			"{", "key:id", "string:a", "key:tags", "[",
+			"key:nested", "{", "key:deep", "[", "}",
+			"key:last", "bool:false", "}",
+		}, nil},
+		{"stop", map[string]error{"key:tags": SkipAll}, []string{
+			"{", "key:id", "string:a", "key:tags",
+		}, nil},
+		{"error", map[string]error{"string:x": errFail}, []string{
+			"{", "key:id", "string:a", "key:tags", "[", "string:x",
+		}, errFail},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := &recorder{signals: test.signals}
+			err := Walk(strings.NewReader(input), r)
+			assert.ErrorIs(t, err, test.err)
+			assert.Equal(t, test.wanted, r.events)
+		})
+	}
+
+	r := &recorder{}
+	assert.NoError(t, Walk(strings.NewReader(input2), r))
+	assert.Equal(t, []string{"number:1", "number:2", "number:3"}, r.events)
+}
+
+type idCollector struct {
+	NopHandler
+	next bool
+	ids  []string
+}
+
+func (c *idCollector) Key(k string) error {
+	c.next = k == "id"
+	return nil
+}
+
+func (c *idCollector) String(s string) error {
+	if c.next {
+		c.ids = append(c.ids, s)
+		c.next = false
+	}
+	return nil
+}
+
+func TestWalkNopHandler(t *testing.T) {
+	c := &idCollector{}
+	err := Walk(strings.NewReader(`[{"id": "a", "v": "x"}, {"v": "y", "id": "b"}]`), c)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, c.ids)
+
+	assert.Error(t, Walk(strings.NewReader(`[{"id": "a",}]`), c))
+}